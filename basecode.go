@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// loadBaseCode загружает шаблон задания, выданный преподавателем, как одну работу
+func loadBaseCode(dir string) (Project, error) {
+	base, err := loadSubmission("base", dir)
+	if err != nil {
+		return Project{}, fmt.Errorf("базовый код: %w", err)
+	}
+	return base, nil
+}
+
+// baseCodeHashes возвращает хеши всех k-грамм базового кода (а не только отобранных
+// winnowing), чтобы из работ вырезались все фрагменты шаблона
+func baseCodeHashes(base Project) map[uint64]bool {
+	hashes := make(map[uint64]bool)
+	for _, file := range base.Files {
+		for _, h := range kGramHashes(file.Tokens.TokenPatterns, config.Fingerprint.K) {
+			hashes[h] = true
+		}
+	}
+	return hashes
+}
+
+// excludeCode убирает из работы отпечатки с указанными хешами и маскирует токены
+// совпавших k-грамм, чтобы GST и n-граммы тоже их не учитывали.
+// Возвращает количество удаленных отпечатков.
+func excludeCode(p *Project, hashes map[uint64]bool) int {
+	if len(hashes) == 0 {
+		return 0
+	}
+
+	removed := 0
+	for i := range p.Files {
+		file := &p.Files[i]
+
+		// Хеши считаем до маскирования, иначе соседние k-граммы изменятся
+		kgrams := kGramHashes(file.Tokens.TokenPatterns, config.Fingerprint.K)
+		for pos, h := range kgrams {
+			if !hashes[h] {
+				continue
+			}
+			for k := pos; k < pos+config.Fingerprint.K; k++ {
+				// Уникальная заглушка не совпадет ни с одним токеном другой работы
+				mask := fmt.Sprintf("\x00%s:%d", p.Name, file.TokenOffset+k)
+				file.Tokens.TokenPatterns[k] = mask
+				p.Tokens.TokenPatterns[file.TokenOffset+k] = mask
+			}
+		}
+
+		kept := file.Fingerprints[:0]
+		for _, fp := range file.Fingerprints {
+			if hashes[fp.Hash] {
+				removed++
+				continue
+			}
+			kept = append(kept, fp)
+		}
+		file.Fingerprints = kept
+	}
+
+	kept := p.Fingerprints[:0]
+	for _, fp := range p.Fingerprints {
+		if !hashes[fp.Hash] {
+			kept = append(kept, fp)
+		}
+	}
+	p.Fingerprints = kept
+
+	return removed
+}
+
+// subtractBaseCode вычитает шаблон задания из работы по всем метрикам
+// и запоминает, какая доля отпечатков работы пришлась на шаблон.
+// Вычитание не идемпотентно: имена, слова комментариев и счетчики конструкций
+// шаблона снимаются по количеству, поэтому вызывать его для работы можно только
+// один раз - на свежем анализе.
+func subtractBaseCode(p *Project, base Project, hashes map[uint64]bool) {
+	total := len(p.Fingerprints)
+	removed := excludeCode(p, hashes)
+	if total > 0 {
+		p.BaseRemoved = float64(removed) / float64(total) * 100
+	}
+
+	// Идентификаторы, объявленные шаблоном: каждое объявление шаблона снимает одно
+	// объявление студента, а собственные i, n, result студента остаются
+	ids := &p.Identifiers
+	baseIds := base.Identifiers
+	ids.Variables = withoutDeclarations(ids.Variables, baseIds.Variables)
+	ids.Functions = withoutDeclarations(ids.Functions, baseIds.Functions)
+	ids.Classes = withoutDeclarations(ids.Classes, baseIds.Classes)
+	ids.Interfaces = withoutDeclarations(ids.Interfaces, baseIds.Interfaces)
+	ids.Constants = withoutDeclarations(ids.Constants, baseIds.Constants)
+
+	// Функции шаблона: по именам, а тела - только если студент их не менял
+	for _, name := range base.Functions.DeclareOrder {
+		delete(p.Functions.ParamCount, name)
+		delete(p.Functions.ReturnTypes, name)
+		delete(p.Functions.FunctionSizes, name)
+		delete(p.Functions.ParamTypes, name)
+	}
+	p.Functions.DeclareOrder = withoutElements(p.Functions.DeclareOrder, base.Functions.DeclareOrder)
+
+	baseBodies := make(map[uint64]bool)
+	for _, body := range base.Bodies {
+		baseBodies[body.BodyHash] = true
+	}
+	var bodies []FunctionFingerprint
+	for _, body := range p.Bodies {
+		if !baseBodies[body.BodyHash] {
+			bodies = append(bodies, body)
+		}
+	}
+	p.Bodies = bodies
+
+	// Импорты шаблона
+	p.Imports.ImportList = withoutElements(p.Imports.ImportList, base.Imports.ImportList)
+	p.Imports.ImportOrder = strings.Join(p.Imports.ImportList, ",")
+	for pkg, usage := range base.Imports.UsagePatterns {
+		if p.Imports.UsagePatterns[pkg] == usage {
+			delete(p.Imports.UsagePatterns, pkg)
+		}
+	}
+
+	// Комментарии шаблона ("// TODO: ваш код здесь") - по словам
+	p.Comments = strings.Join(withoutDeclarations(strings.Fields(p.Comments), strings.Fields(base.Comments)), " ")
+
+	// Управляющие конструкции шаблона: его счетчики и шаблоны неизмененных файлов.
+	// Глубину вложенности вычесть нельзя, она остается как есть.
+	cf, baseCF := &p.ControlFlow, base.ControlFlow
+	cf.IfCount = max(cf.IfCount-baseCF.IfCount, 0)
+	cf.ForCount = max(cf.ForCount-baseCF.ForCount, 0)
+	cf.WhileCount = max(cf.WhileCount-baseCF.WhileCount, 0)
+	cf.SwitchCount = max(cf.SwitchCount-baseCF.SwitchCount, 0)
+	var patterns, basePatterns []string
+	for _, file := range p.Files {
+		patterns = appendNonEmpty(patterns, file.ControlFlow.ControlPattern)
+	}
+	for _, file := range base.Files {
+		basePatterns = appendNonEmpty(basePatterns, file.ControlFlow.ControlPattern)
+	}
+	cf.ControlPattern = strings.Join(withoutDeclarations(patterns, basePatterns), "|")
+
+	// Из структуры AST и промежуточного представления вырезаются n-граммы шаблона.
+	// Позиции в них нигде не используются, поэтому элементы удаляются, а не
+	// маскируются: заглушки занижали бы схожесть собственного кода работ.
+	astHashes, irHashes := make(map[uint64]bool), make(map[uint64]bool)
+	for _, file := range base.Files {
+		for _, h := range kGramHashes(file.ASTShape, astNGramSize) {
+			astHashes[h] = true
+		}
+		for _, h := range kGramHashes(file.Tokens.IR, irMinMatch) {
+			irHashes[h] = true
+		}
+	}
+	p.ASTShape, p.Tokens.IR = nil, nil
+	for i := range p.Files {
+		file := &p.Files[i]
+		file.ASTShape = withoutKGrams(file.ASTShape, astHashes, astNGramSize)
+		file.Tokens.IR = withoutKGrams(file.Tokens.IR, irHashes, irMinMatch)
+		p.ASTShape = append(p.ASTShape, file.ASTShape...)
+		p.Tokens.IR = append(p.Tokens.IR, file.Tokens.IR...)
+	}
+
+	p.Available = detectAvailableMetrics(p.SourceFile)
+}
+
+// withoutKGrams возвращает элементы seq, не покрытые ни одной n-граммой с хешем из hashes
+func withoutKGrams(seq []string, hashes map[uint64]bool, n int) []string {
+	covered := make([]bool, len(seq))
+	for pos, h := range kGramHashes(seq, n) {
+		if hashes[h] {
+			for k := pos; k < pos+n; k++ {
+				covered[k] = true
+			}
+		}
+	}
+
+	var kept []string
+	for i, item := range seq {
+		if !covered[i] {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// withoutDeclarations убирает из list столько вхождений каждого имени, сколько раз
+// оно встречается в declared. Повторный вызов с тем же declared снимет имена еще раз.
+func withoutDeclarations(list, declared []string) []string {
+	remaining := make(map[string]int)
+	for _, name := range declared {
+		remaining[name]++
+	}
+
+	var result []string
+	for _, name := range list {
+		if remaining[name] > 0 {
+			remaining[name]--
+			continue
+		}
+		result = append(result, name)
+	}
+	return result
+}
+
+// withoutElements возвращает элементы list, которых нет в exclude
+func withoutElements(list, exclude []string) []string {
+	excluded := make(map[string]bool)
+	for _, item := range exclude {
+		excluded[item] = true
+	}
+
+	var result []string
+	for _, item := range list {
+		if !excluded[item] {
+			result = append(result, item)
+		}
+	}
+	return result
+}
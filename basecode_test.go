@@ -0,0 +1,124 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWithoutDeclarations(t *testing.T) {
+	tests := []struct {
+		name     string
+		list     []string
+		declared []string
+		want     []string
+	}{
+		{"шаблон пуст", []string{"i", "n"}, nil, []string{"i", "n"}},
+		{"имя шаблона снимается один раз", []string{"i", "total", "i", "i"}, []string{"i"}, []string{"total", "i", "i"}},
+		{"все объявления шаблона", []string{"result", "result"}, []string{"result", "result"}, nil},
+		{"имени нет у студента", []string{"x"}, []string{"y"}, []string{"x"}},
+	}
+	for _, tt := range tests {
+		if got := withoutDeclarations(tt.list, tt.declared); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: получено %v, ожидалось %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSubtractBaseCodeKeepsStudentNames(t *testing.T) {
+	base := Project{SourceFile: SourceFile{Identifiers: Identifiers{
+		Variables: []string{"i", "result"},
+		Functions: []string{"readInput"},
+	}}}
+	p := Project{SourceFile: SourceFile{
+		Identifiers: Identifiers{
+			Variables: []string{"i", "result", "i", "n"},
+			Functions: []string{"readInput", "solve"},
+		},
+		Functions: FunctionAnalysis{
+			ParamCount:    map[string]int{},
+			ReturnTypes:   map[string]string{},
+			FunctionSizes: map[string]int{},
+			ParamTypes:    map[string]string{},
+		},
+		Imports: ImportAnalysis{UsagePatterns: map[string]string{}},
+	}}
+
+	subtractBaseCode(&p, base, nil)
+
+	if want := []string{"i", "n"}; !reflect.DeepEqual(p.Identifiers.Variables, want) {
+		t.Errorf("переменные: %v, ожидалось %v", p.Identifiers.Variables, want)
+	}
+	if want := []string{"solve"}; !reflect.DeepEqual(p.Identifiers.Functions, want) {
+		t.Errorf("функции: %v, ожидалось %v", p.Identifiers.Functions, want)
+	}
+}
+
+func TestSubtractBaseCodeStructure(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+	config.CacheDir = ""
+
+	const template = `package main
+
+import "fmt"
+
+// TODO: реализуйте solve
+func main() {
+	var n int
+	fmt.Scan(&n)
+	for i := 0; i < n; i++ {
+		if i%2 == 0 {
+			fmt.Println(i)
+		}
+	}
+}
+`
+	own := `
+// Сумма квадратов
+func solve(xs []int) int {
+	total := 0
+	for _, x := range xs {
+		if x > 0 {
+			total += x * x
+		}
+	}
+	return total
+}
+`
+	project := func(name, src string) Project {
+		dir := filepath.Join("work", name)
+		file := analyzeFile(filepath.Join(dir, "main.go"), src, "golang")
+		return buildProject(name, dir, []SourceFile{file})
+	}
+	base := project("base", template)
+	p := project("alice", template+own)
+
+	subtractBaseCode(&p, base, baseCodeHashes(base))
+
+	if p.Comments != "сумма квадратов" {
+		t.Errorf("комментарии %q, ожидались только свои", p.Comments)
+	}
+	if cf := p.ControlFlow; cf.ForCount != 1 || cf.IfCount != 1 {
+		t.Errorf("управляющие конструкции %+v, ожидались только свои for и if", cf)
+	}
+	if s := compareASTShapes(p.ASTShape, base.ASTShape); s != 0 {
+		t.Errorf("AST после вычитания совпадает с шаблоном на %.2f%%", s)
+	}
+	if s, _, _ := compareIR(p.Tokens.IR, base.Tokens.IR); s != 0 {
+		t.Errorf("IR после вычитания совпадает с шаблоном на %.2f%%", s)
+	}
+	if !reflect.DeepEqual(p.ASTShape, p.Files[0].ASTShape) || !reflect.DeepEqual(p.Tokens.IR, p.Files[0].Tokens.IR) {
+		t.Error("сводный анализ работы расходится с анализом ее файла")
+	}
+
+	// Своя часть работы после вычитания по-прежнему сравнима
+	other := project("bob", template+own)
+	subtractBaseCode(&other, base, baseCodeHashes(base))
+	if s := compareASTShapes(p.ASTShape, other.ASTShape); s != 100 {
+		t.Errorf("AST одинаковых решений совпадает на %.2f%%", s)
+	}
+	if s, _, _ := compareIR(p.Tokens.IR, other.Tokens.IR); s != 100 {
+		t.Errorf("IR одинаковых решений совпадает на %.2f%%", s)
+	}
+}
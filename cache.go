@@ -0,0 +1,114 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// analyzerVersion меняется при любом изменении анализаторов или сравнения,
+// после чего старые записи кэша перестают находиться
+const analyzerVersion = "13"
+
+// cacheKey считает ключ кэша по версии анализатора и частям, от которых зависит результат
+func cacheKey(parts ...string) string {
+	h := sha256.New()
+	h.Write([]byte(analyzerVersion))
+	for _, part := range parts {
+		// Длина перед каждой частью, чтобы "ab"+"c" и "a"+"bc" не совпадали
+		fmt.Fprintf(h, "\x00%d:", len(part))
+		h.Write([]byte(part))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cachePath возвращает путь записи кэша; записи раскладываются по папкам по
+// первым символам ключа, чтобы в одной папке не было десятков тысяч файлов
+func cachePath(kind, key string) string {
+	return filepath.Join(config.CacheDir, kind, key[:2], key+".json")
+}
+
+// readCache читает запись кэша в value. Отсутствующая или испорченная запись - промах.
+func readCache(kind, key string, value interface{}) bool {
+	data, err := ioutil.ReadFile(cachePath(kind, key))
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, value) == nil
+}
+
+// writeCache сохраняет запись кэша. Кэш только ускоряет проверку, поэтому
+// ошибки записи не прерывают работу.
+func writeCache(kind, key string, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	path := cachePath(kind, key)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return
+	}
+	// Запись через временный файл: параллельный читатель не увидит половину записи
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0o644); err != nil {
+		return
+	}
+	os.Rename(tmp, path)
+}
+
+// analyzeFileCached анализирует файл или берет готовый анализ из кэша по хэшу
+// содержимого. Второе значение сообщает, был ли анализ взят из кэша.
+func analyzeFileCached(path, rawContent, lang string) (SourceFile, bool) {
+	if config.CacheDir == "" {
+		return analyzeFile(path, rawContent, lang), false
+	}
+
+	key := cacheKey("file", lang, fmt.Sprint(config.Fingerprint), rawContent)
+	var file SourceFile
+	if readCache("files", key, &file) {
+		// Тот же код мог лежать по другому пути, в том числе в работе другого студента
+		file.FilePath = path
+		for i := range file.Bodies {
+			file.Bodies[i].Location.File = path
+		}
+		return file, true
+	}
+
+	file = analyzeFile(path, rawContent, lang)
+	writeCache("files", key, file)
+	return file, false
+}
+
+// projectDigest считает отпечаток подготовленной работы целиком: имя, файлы
+// и анализ после исключения базового и общего кода
+func projectDigest(p Project) string {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return ""
+	}
+	return cacheKey("project", string(data))
+}
+
+// compareProjectsCached сравнивает пару или берет результат из кэша, если обе
+// работы не изменились с прошлого запуска. Общая оценка всегда пересчитывается
+// с текущими весами.
+func compareProjectsCached(p1, p2 Project, digest1, digest2 string) (ComparisonResult, bool) {
+	if config.CacheDir == "" || digest1 == "" || digest2 == "" {
+		return compareProjects(p1, p2), false
+	}
+
+	key := cacheKey("pair", digest1, digest2, config.Algorithm, fmt.Sprint(config.MinMatchLength))
+	var result ComparisonResult
+	if readCache("pairs", key, &result) {
+		result.OverallScore = config.Scoring.overallScore(result)
+		return result, true
+	}
+
+	result = compareProjects(p1, p2)
+	writeCache("pairs", key, result)
+	return result, false
+}
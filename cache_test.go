@@ -0,0 +1,114 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCacheKey(t *testing.T) {
+	if cacheKey("ab", "c") == cacheKey("a", "bc") {
+		t.Error("разное деление на части дало один ключ")
+	}
+	if cacheKey("file", "x") != cacheKey("file", "x") {
+		t.Error("одинаковые части дали разные ключи")
+	}
+}
+
+func TestAnalyzeFileCached(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+	config.CacheDir = t.TempDir()
+
+	src := "def add(a, b):\n    return a + b\n"
+	first, cached := analyzeFileCached("alice/main.py", src, "python")
+	if cached {
+		t.Fatal("первый анализ не должен браться из кэша")
+	}
+	second, cached := analyzeFileCached("bob/main.py", src, "python")
+	if !cached {
+		t.Fatal("тот же код должен браться из кэша")
+	}
+	if second.FilePath != "bob/main.py" {
+		t.Errorf("FilePath = %q, ожидалось bob/main.py", second.FilePath)
+	}
+	if !reflect.DeepEqual(first.Tokens, second.Tokens) || !reflect.DeepEqual(first.Fingerprints, second.Fingerprints) {
+		t.Error("анализ из кэша отличается от исходного")
+	}
+	if _, cached := analyzeFileCached("bob/main.py", src+"\n# изменено\n", "python"); cached {
+		t.Error("измененный код взят из кэша")
+	}
+
+	config.CacheDir = ""
+	if _, cached := analyzeFileCached("carol/main.py", src, "python"); cached {
+		t.Error("без папки кэша анализ не должен браться из кэша")
+	}
+}
+
+func TestCompareProjectsCachedRescores(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+	config.CacheDir = t.TempDir()
+
+	project := func(name, src string) Project {
+		dir := filepath.Join("work", name)
+		return buildProject(name, dir, []SourceFile{analyzeFile(filepath.Join(dir, "main.py"), src, "python")})
+	}
+	p1 := project("alice", "def add(a, b):\n    # сумма\n    return a + b\n")
+	p2 := project("bob", "def plus(x, y):\n    return x + y\n")
+	d1, d2 := projectDigest(p1), projectDigest(p2)
+
+	first, cached := compareProjectsCached(p1, p2, d1, d2)
+	if cached {
+		t.Fatal("первое сравнение не должно браться из кэша")
+	}
+
+	// Веса изменились: результат берется из кэша, а общая оценка пересчитывается
+	config.Scoring.Weights = map[string]float64{metricText: 1}
+	second, cached := compareProjectsCached(p1, p2, d1, d2)
+	if !cached {
+		t.Fatal("та же пара должна браться из кэша")
+	}
+	if second.OverallScore != second.Similarity || second.Similarity != first.Similarity {
+		t.Errorf("общая оценка %.2f не пересчитана по новым весам (код %.2f)", second.OverallScore, second.Similarity)
+	}
+}
+
+func TestAnalyzeFileCachedRewritesPaths(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+	config.CacheDir = t.TempDir()
+
+	src := `package main
+
+func sum(xs []int) int {
+	total := 0
+	for _, x := range xs {
+		if x > 0 {
+			total += x
+		}
+	}
+	return total
+}
+`
+	first, cached := analyzeFileCached("alice/main.go", src, "golang")
+	if cached {
+		t.Fatal("первый анализ не должен браться из кэша")
+	}
+	if len(first.Bodies) == 0 {
+		t.Fatal("не найдено ни одного тела функции")
+	}
+
+	second, cached := analyzeFileCached("bob/main.go", src, "golang")
+	if !cached {
+		t.Fatal("тот же код должен браться из кэша")
+	}
+	if second.FilePath != "bob/main.go" {
+		t.Errorf("FilePath = %q, ожидалось bob/main.go", second.FilePath)
+	}
+	for _, body := range second.Bodies {
+		if body.Location.File != "bob/main.go" {
+			t.Errorf("функция %s: Location.File = %q, ожидалось bob/main.go", body.Name, body.Location.File)
+		}
+	}
+}
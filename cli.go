@@ -0,0 +1,451 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Коды завершения программы
+const (
+	exitOK         = 0 // проверка выполнена, подозрительных пар нет
+	exitSuspicious = 1 // проверка выполнена, найдены пары не ниже порога
+	exitUsage      = 2 // ошибка в аргументах или настройках
+	exitFailure    = 3 // ошибка при работе (чтение файлов, запись отчетов)
+)
+
+const usageText = `Проверка студенческих работ на плагиат.
+
+Использование:
+  plagiarism [scan] [флаги]            проверить все работы из папки -input
+  plagiarism compare [флаги] A B       сравнить две папки с работами
+  plagiarism report [флаги]            заново построить отчеты по последнему scan
+  plagiarism explain [флаги] A B       разобрать оценку пары работ из последнего scan
+  plagiarism help                      показать эту справку
+
+Флаги указываются до позиционных аргументов. Справка по флагам: plagiarism <команда> -h
+
+Настройки курса можно хранить в JSON-файле (-config, по умолчанию plagiarism.json
+в текущей папке, если он есть); раздел "assignments" задает переопределения для
+отдельных заданий (-assignment). Флаги имеют приоритет над файлом, задание - над
+общими настройками файла. Команды report и explain берут настройки из последнего scan.
+
+С флагом -cross-language сравниваются и работы на разных языках (например, решение
+на Python, построчно переписанное на Java) - по языконезависимому представлению
+кода; для таких пар действует свой порог -cross-threshold.
+
+Коды завершения:
+  0  проверка выполнена, подозрительных пар нет
+  1  найдены пары с оценкой не ниже порога
+  2  ошибка в аргументах или настройках
+  3  ошибка при работе (чтение файлов, запись отчетов)
+`
+
+// runCLI разбирает команду и возвращает код завершения
+func runCLI(args []string) int {
+	command := "scan"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "scan":
+		return runScan(args)
+	case "compare":
+		return runCompare(args)
+	case "report":
+		return runReport(args)
+	case "explain":
+		return runExplain(args)
+	case "help":
+		fmt.Print(usageText)
+		return exitOK
+	default:
+		fmt.Printf("Неизвестная команда %q\n\n", command)
+		fmt.Print(usageText)
+		return exitUsage
+	}
+}
+
+// newFlagSet создает набор флагов команды, привязанный к полям config
+func newFlagSet(command string) *flag.FlagSet {
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+
+	fs.StringVar(&config.ConfigFile, "config", config.ConfigFile, "файл конфигурации курса (по умолчанию "+defaultConfigFile+", если есть)")
+	fs.StringVar(&config.Assignment, "assignment", config.Assignment, "задание из файла конфигурации")
+	fs.StringVar(&config.InputDir, "input", config.InputDir, "папка с работами студентов")
+	fs.StringVar(&config.OutputDir, "output", config.OutputDir, "папка для отчетов")
+	fs.Func("lang", "анализируемые языки через запятую (по умолчанию все)", func(value string) error {
+		config.Languages = splitList(value)
+		return nil
+	})
+	fs.Func("format", "форматы отчетов через запятую: "+strings.Join(reportFormats, ", ")+
+		" (по умолчанию "+strings.Join(config.Formats, ",")+")", func(value string) error {
+		config.Formats = splitList(value)
+		return nil
+	})
+	fs.Func("ignore", "шаблоны путей через запятую, которые не анализируются (например vendor,*_test.go)", func(value string) error {
+		config.Ignore = append(config.Ignore[:len(config.Ignore):len(config.Ignore)], splitList(value)...)
+		return nil
+	})
+	fs.IntVar(&config.Fingerprint.K, "k", config.Fingerprint.K, "длина k-граммы токенов для отпечатков")
+	fs.IntVar(&config.Fingerprint.Window, "window", config.Fingerprint.Window, "размер окна winnowing")
+	fs.StringVar(&config.Algorithm, "algorithm", config.Algorithm, "алгоритм сравнения кода: winnowing, gst или both")
+	fs.IntVar(&config.MinMatchLength, "min-match", config.MinMatchLength, "минимальная длина совпадения GST в токенах")
+	fs.StringVar(&config.BaseDir, "base", config.BaseDir, "папка с шаблоном задания, который исключается из сравнения")
+	fs.Float64Var(&config.CommonFraction, "common", config.CommonFraction, "подавлять фрагменты, встречающиеся в большей доле работ (0 - выключено)")
+	fs.Float64Var(&config.Scoring.Threshold, "threshold", config.Scoring.Threshold, "минимальная общая оценка пары для попадания в отчет")
+	fs.BoolVar(&config.MergeRootFiles, "merge-root-files", config.MergeRootFiles, "присоединять файл alice.py из корня входной папки к работе alice/")
+	fs.BoolVar(&config.CrossLanguage, "cross-language", config.CrossLanguage, "сравнивать работы на разных языках по промежуточному представлению")
+	fs.Float64Var(&config.Scoring.CrossThreshold, "cross-threshold", config.Scoring.CrossThreshold, "порог для пар на разных языках")
+	fs.Float64Var(&config.Scoring.HighBand, "high", config.Scoring.HighBand, "нижняя граница высокой схожести")
+	fs.Float64Var(&config.Scoring.MediumBand, "medium", config.Scoring.MediumBand, "нижняя граница средней схожести")
+	fs.IntVar(&config.Workers, "workers", config.Workers, "число потоков сравнения пар")
+	fs.IntVar(&config.MinShared, "min-shared", config.MinShared, "минимум общих отпечатков (для пар на разных языках - k-грамм IR), чтобы пара с архивной работой сравнивалась (0 - сравнивать все пары)")
+	fs.StringVar(&config.CorpusDir, "corpus", config.CorpusDir, "папка архива работ прошлых лет (пусто - без архива)")
+	fs.StringVar(&config.Course, "course", config.Course, "курс, под которым работы хранятся в архиве")
+	fs.IntVar(&config.Year, "year", config.Year, "год, под которым работы сохраняются в архив")
+	fs.StringVar(&config.CacheDir, "cache", config.CacheDir, "папка кэша анализа и сравнений (по умолчанию кэш не ведется)")
+	fs.StringVar(&config.ClusterMethod, "clusters", config.ClusterMethod, "поиск групп списывания: components (компоненты связности) или modularity (сообщества)")
+	fs.Func("weights", "веса метрик, например text=3,tokens=2 (по умолчанию "+formatWeights(config.Scoring.Weights)+")",
+		func(value string) error {
+			weights, err := parseWeights(value)
+			if err != nil {
+				return err
+			}
+			for metric, weight := range weights {
+				config.Scoring.Weights[metric] = weight
+			}
+			return nil
+		})
+
+	return fs
+}
+
+// parseFlags разбирает флаги команды поверх настроек base и проверяет число
+// позиционных аргументов. Первый проход нужен только затем, чтобы base знала
+// -config, -assignment и -output; второй применяет все флаги поверх нее.
+func parseFlags(command string, args []string, positional int, base func() (Config, error)) ([]string, bool) {
+	fs := newFlagSet(command)
+	if err := fs.Parse(args); err != nil {
+		return nil, false
+	}
+
+	baseConfig, err := base()
+	if err != nil {
+		fmt.Printf("Ошибка в настройках: %v\n", err)
+		return nil, false
+	}
+	config = baseConfig
+
+	fs = newFlagSet(command)
+	if err := fs.Parse(args); err != nil {
+		return nil, false
+	}
+	if fs.NArg() != positional {
+		fmt.Printf("Команда %s ожидает аргументов: %d, получено: %d\n\n", command, positional, fs.NArg())
+		fmt.Print(usageText)
+		return nil, false
+	}
+	if err := config.validate(); err != nil {
+		fmt.Printf("Ошибка в настройках: %v\n", err)
+		return nil, false
+	}
+	return fs.Args(), true
+}
+
+// configuredBase возвращает настройки по умолчанию с наложенным файлом конфигурации
+func configuredBase() (Config, error) {
+	c := defaultConfig()
+	c.ConfigFile, c.Assignment = config.ConfigFile, config.Assignment
+	err := loadConfigFile(&c)
+	return c, err
+}
+
+// runScan проверяет все работы из папки InputDir
+func runScan(args []string) int {
+	if _, ok := parseFlags("scan", args, 0, configuredBase); !ok {
+		return exitUsage
+	}
+
+	projects, err := loadProjects(config.InputDir)
+	if err != nil {
+		fmt.Printf("Ошибка при загрузке проектов: %v\n", err)
+		return exitFailure
+	}
+
+	var archive []Project
+	if config.CorpusDir != "" {
+		if archive, err = loadCorpus(); err != nil {
+			fmt.Printf("Ошибка при загрузке архива: %v\n", err)
+			return exitFailure
+		}
+		fmt.Printf("Загружено работ из архива: %d\n\n", len(archive))
+	}
+
+	// В архив попадает анализ до вычитания базового и общего кода: вычитание
+	// не идемпотентно, а при загрузке из архива шаблон вычитается заново
+	if config.CorpusDir != "" {
+		if err := saveToCorpus(projects); err != nil {
+			fmt.Printf("Ошибка при сохранении в архив: %v\n", err)
+			return exitFailure
+		}
+	}
+
+	if err := prepareProjects(projects, archive); err != nil {
+		fmt.Printf("Ошибка при подготовке проектов: %v\n", err)
+		return exitFailure
+	}
+
+	progress := make(chan ComparisonProgress)
+	shown := make(chan struct{})
+	go func() {
+		showProgress(progress)
+		close(shown)
+	}()
+	results, stats := compareAllProjects(append(projects, archive...), config.Workers, progress)
+	<-shown
+	if stats.Pruned > 0 {
+		fmt.Printf("Отсеяно по индексу отпечатков пар с архивом: %d из %d пар\n", stats.Pruned, stats.TotalPairs)
+	}
+	if stats.CrossPairs > 0 {
+		fmt.Printf("Пар на разных языках: %d (порог %g%%)\n", stats.CrossPairs, config.Scoring.CrossThreshold)
+	}
+	if stats.Cached > 0 {
+		fmt.Printf("Результаты взяты из кэша: %d из %d пар\n", stats.Cached, stats.Compared)
+	}
+	fmt.Println()
+	summaries := summarizeProjects(projects)
+
+	state := runState{Config: config, Projects: summaries, Results: results, Stats: stats}
+	if err := saveRunState(config.OutputDir, state); err != nil {
+		fmt.Printf("Ошибка при сохранении результатов: %v\n", err)
+		return exitFailure
+	}
+
+	return reportResults(summaries, results, stats)
+}
+
+// prepareProjects исключает из работ базовый код и общие для когорты фрагменты.
+// Из архивных работ исключается только базовый код: когорта - это текущий запуск.
+func prepareProjects(projects, archive []Project) error {
+	if config.BaseDir != "" {
+		base, err := loadBaseCode(config.BaseDir)
+		if err != nil {
+			return err
+		}
+		hashes := baseCodeHashes(base)
+		for i := range projects {
+			subtractBaseCode(&projects[i], base, hashes)
+			fmt.Printf("Из проекта %s исключено как базовый код: %.2f%%\n", projects[i].Name, projects[i].BaseRemoved)
+		}
+		for i := range archive {
+			subtractBaseCode(&archive[i], base, hashes)
+		}
+		fmt.Println()
+	}
+
+	if config.CommonFraction > 0 {
+		suppressed := suppressCommonCode(projects, config.CommonFraction)
+		fmt.Printf("Подавлено общих для когорты отпечатков: %d\n\n", suppressed)
+	}
+	return nil
+}
+
+// showProgress печатает ход сравнения и пары не ниже порога, пока канал не закрыт
+func showProgress(progress <-chan ComparisonProgress) {
+	fmt.Printf("Начинаю сравнение проектов (потоков: %d)...\n", config.Workers)
+
+	total := 0
+	for p := range progress {
+		total = p.Total
+		r := p.Result
+		if config.Scoring.flagged(r) {
+			found := fmt.Sprintf("Обнаружена схожесть %s и %s: %.2f%%", r.Project1, r.Project2, r.OverallScore)
+			if r.ArchiveYear != 0 {
+				found += fmt.Sprintf(" (архив %d)", r.ArchiveYear)
+			}
+			fmt.Printf("\r%-60s\n", found)
+			for _, clone := range r.Clones {
+				fmt.Printf("  Похожие функции: %s ~ %s (%.2f%%)%s\n", clone.Function1, clone.Function2, clone.Similarity, cloneLocations(clone))
+			}
+		}
+		fmt.Printf("\rПрогресс: %d/%d сравнений", p.Done, p.Total)
+	}
+	fmt.Printf("\nЗавершено сравнение проектов (%d пар)\n\n", total)
+}
+
+// reportResults отбирает пары по порогу, пишет отчеты и выбирает код завершения
+func reportResults(projects []ProjectSummary, results []ComparisonResult, stats ComparisonStats) int {
+	if err := printResults(projects, results, stats); err != nil {
+		fmt.Printf("Ошибка при создании отчета: %v\n", err)
+		return exitFailure
+	}
+	if len(config.Scoring.filterResults(results)) > 0 {
+		return exitSuspicious
+	}
+	return exitOK
+}
+
+// runCompare сравнивает две папки с работами
+func runCompare(args []string) int {
+	dirs, ok := parseFlags("compare", args, 2, configuredBase)
+	if !ok {
+		return exitUsage
+	}
+
+	name1, name2 := filepath.Base(dirs[0]), filepath.Base(dirs[1])
+	if name1 == name2 {
+		name1, name2 = dirs[0], dirs[1]
+	}
+
+	p1, err := loadSubmission(name1, dirs[0])
+	if err != nil {
+		fmt.Printf("Ошибка при загрузке проекта: %v\n", err)
+		return exitFailure
+	}
+	p2, err := loadSubmission(name2, dirs[1])
+	if err != nil {
+		fmt.Printf("Ошибка при загрузке проекта: %v\n", err)
+		return exitFailure
+	}
+
+	projects := []Project{p1, p2}
+	if err := prepareProjects(projects, nil); err != nil {
+		fmt.Printf("Ошибка при подготовке проектов: %v\n", err)
+		return exitFailure
+	}
+	if projects[0].Language != projects[1].Language {
+		fmt.Printf("Внимание: проекты написаны на разных языках (%s и %s), сравнивается промежуточное представление\n\n",
+			projects[0].Language, projects[1].Language)
+	}
+
+	result := compareProjects(projects[0], projects[1])
+	explainResult(result, config.Scoring)
+
+	if config.Scoring.flagged(result) {
+		return exitSuspicious
+	}
+	return exitOK
+}
+
+// restoreRunState загружает последний запуск и подставляет его настройки;
+// флаги, указанные явно, имеют приоритет над сохраненными
+func restoreRunState(command string, args []string, positional int) (runState, []string, bool) {
+	if _, ok := parseFlags(command, args, positional, configuredBase); !ok {
+		return runState{}, nil, false
+	}
+
+	state, err := loadRunState(config.OutputDir)
+	if err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		return runState{}, nil, false
+	}
+
+	rest, ok := parseFlags(command, args, positional, func() (Config, error) { return state.Config, nil })
+	if !ok {
+		return runState{}, nil, false
+	}
+
+	// Веса и порог могли измениться - пересчитываем общие оценки
+	config.Scoring.rescore(state.Results)
+	return state, rest, true
+}
+
+// runReport заново строит отчеты по сохраненным результатам
+func runReport(args []string) int {
+	state, _, ok := restoreRunState("report", args, 0)
+	if !ok {
+		return exitUsage
+	}
+	return reportResults(state.Projects, state.Results, state.Stats)
+}
+
+// runExplain подробно разбирает оценку одной пары из последнего запуска
+func runExplain(args []string) int {
+	state, names, ok := restoreRunState("explain", args, 2)
+	if !ok {
+		return exitUsage
+	}
+
+	result, found := findResult(state.Results, names[0], names[1])
+	if !found {
+		fmt.Printf("Пара %s и %s не сравнивалась (проверьте имена и языки проектов)\n", names[0], names[1])
+		return exitFailure
+	}
+
+	explainResult(result, config.Scoring)
+	if config.Scoring.flagged(result) {
+		return exitSuspicious
+	}
+	return exitOK
+}
+
+// explainResult печатает вклад каждой метрики в общую оценку пары
+func explainResult(r ComparisonResult, scoring ScoringConfig) {
+	fmt.Printf("Проекты: %s и %s (язык: %s)\n", r.Project1, r.Project2, r.Language)
+	if r.ArchiveYear != 0 {
+		fmt.Printf("Совпадение с архивной работой %d года\n", r.ArchiveYear)
+	}
+	fmt.Println()
+
+	if r.CrossLanguage {
+		fmt.Printf("Работы на разных языках сравнены по промежуточному представлению: %.2f%%\n", r.IRSimilarity)
+		fmt.Printf("%.2f%% кода %s найдено в %s\n", r.Containment1, r.Project1, r.Project2)
+		fmt.Printf("%.2f%% кода %s найдено в %s\n", r.Containment2, r.Project2, r.Project1)
+		fmt.Printf("\nОбщая оценка: %.2f%% (%s), порог для разных языков: %g%%\n",
+			r.OverallScore, bandLabels[scoring.band(r.OverallScore)], scoring.CrossThreshold)
+		return
+	}
+
+	var totalWeight float64
+	for _, metric := range allMetrics {
+		if r.IsAvailable(metric) {
+			totalWeight += scoring.Weights[metric]
+		}
+	}
+
+	fmt.Printf("%-28s %10s %6s %8s\n", "Метрика", "Значение", "Вес", "Вклад")
+	for _, metric := range allMetrics {
+		weight := scoring.Weights[metric]
+		if !r.IsAvailable(metric) {
+			fmt.Printf("%-28s %10s %6g %8s\n", metricLabels[metric], "н/д", weight, "-")
+			continue
+		}
+		contribution := 0.0
+		if totalWeight > 0 {
+			contribution = r.MetricValue(metric) * weight / totalWeight
+		}
+		fmt.Printf("%-28s %9.2f%% %6g %7.2f%%\n", metricLabels[metric], r.MetricValue(metric), weight, contribution)
+	}
+
+	fmt.Printf("\nОбщая оценка: %.2f%% (%s), порог: %g%%\n",
+		r.OverallScore, bandLabels[scoring.band(r.OverallScore)], scoring.Threshold)
+	if r.IsAvailable(metricText) {
+		fmt.Printf("%.2f%% кода %s найдено в %s\n", r.Containment1, r.Project1, r.Project2)
+		fmt.Printf("%.2f%% кода %s найдено в %s\n", r.Containment2, r.Project2, r.Project1)
+	}
+
+	if len(r.Matches) > 0 {
+		fmt.Printf("Совпавших отпечатков: %d\n", len(r.Matches))
+	}
+	if len(r.Tiles) > 0 {
+		fmt.Printf("Совпавших отрезков GST: %d (покрытие %.2f%%)\n", len(r.Tiles), r.TileCoverage)
+	}
+	for _, f := range r.Fragments {
+		loc1, loc2 := r.fragmentLocations(f)
+		fmt.Printf("Совпавший фрагмент: %s ~ %s (%d токенов)\n", loc1, loc2, f.Tokens)
+	}
+	for _, clone := range r.Clones {
+		fmt.Printf("Похожие функции: %s ~ %s (%.2f%%)%s\n", clone.Function1, clone.Function2, clone.Similarity, cloneLocations(clone))
+	}
+}
+
+// cloneLocations печатает места объявления функций клона, если они известны
+func cloneLocations(clone FunctionClone) string {
+	if clone.Location1.Line == 0 || clone.Location2.Line == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" [%s ~ %s]", clone.Location1, clone.Location2)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFlags(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+
+	tests := []struct {
+		name       string
+		command    string
+		args       []string
+		positional int
+		ok         bool
+		rest       []string
+		check      func(Config) bool
+	}{
+		{
+			name: "флаги scan", command: "scan",
+			args: []string{"-input", "works", "-k", "5", "-lang", "golang, python", "-algorithm", "both"},
+			ok:   true,
+			check: func(c Config) bool {
+				return c.InputDir == "works" && c.Fingerprint.K == 5 && c.Algorithm == algorithmBoth &&
+					reflect.DeepEqual(c.Languages, []string{"golang", "python"})
+			},
+		},
+		{
+			name: "веса меняют только перечисленные метрики", command: "scan",
+			args: []string{"-weights", "text=0,ast=4"},
+			ok:   true,
+			check: func(c Config) bool {
+				return c.Scoring.Weights["text"] == 0 && c.Scoring.Weights["ast"] == 4 &&
+					c.Scoring.Weights["tokens"] == defaultScoringConfig().Weights["tokens"]
+			},
+		},
+		{
+			name: "позиционные аргументы compare", command: "compare",
+			args: []string{"-threshold", "40", "a", "b"}, positional: 2,
+			ok: true, rest: []string{"a", "b"},
+			check: func(c Config) bool { return c.Scoring.Threshold == 40 },
+		},
+		{name: "флаг после позиционных аргументов", command: "compare", args: []string{"a", "b", "-k", "3"}, positional: 2},
+		{name: "не хватает аргументов", command: "explain", args: []string{"a"}, positional: 2},
+		{name: "лишний аргумент", command: "scan", args: []string{"works"}},
+		{name: "неизвестный флаг", command: "scan", args: []string{"-fast"}},
+		{name: "нечисловое значение", command: "scan", args: []string{"-k", "seven"}},
+		{name: "некорректные веса", command: "scan", args: []string{"-weights", "text"}},
+		{name: "неподдерживаемый язык", command: "scan", args: []string{"-lang", "cobol"}},
+		{name: "неизвестный формат", command: "scan", args: []string{"-format", "pdf"}},
+		{name: "недопустимое значение", command: "scan", args: []string{"-k", "0"}},
+		{name: "неизвестный алгоритм", command: "scan", args: []string{"-algorithm", "diff"}},
+	}
+	defaults := func() (Config, error) { return defaultConfig(), nil }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rest, ok := parseFlags(tt.command, tt.args, tt.positional, defaults)
+			if ok != tt.ok {
+				t.Fatalf("разбор %v: ok = %v, ожидалось %v", tt.args, ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if len(rest) != 0 || len(tt.rest) != 0 {
+				if !reflect.DeepEqual(rest, tt.rest) {
+					t.Errorf("позиционные аргументы %v, ожидалось %v", rest, tt.rest)
+				}
+			}
+			if tt.check != nil && !tt.check(config) {
+				t.Errorf("настройки после разбора %v: %+v", tt.args, config)
+			}
+		})
+	}
+}
+
+func TestRunCLIUsageErrors(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{[]string{"help"}, exitOK},
+		{[]string{"check"}, exitUsage},
+		{[]string{"compare", "only-one"}, exitUsage},
+		{[]string{"explain"}, exitUsage},
+		{[]string{"-k", "-1"}, exitUsage},
+	}
+	for _, tt := range tests {
+		config = defaultConfig()
+		if got := runCLI(tt.args); got != tt.want {
+			t.Errorf("runCLI(%q) = %d, ожидалось %d", tt.args, got, tt.want)
+		}
+	}
+}
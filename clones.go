@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/binary"
+	"go/ast"
+	"go/token"
+	"hash/fnv"
+	"sort"
+)
+
+// Функции меньше этого числа узлов AST слишком тривиальны для поиска клонов
+const cloneMinFunctionSize = 8
+
+// То же для функций, разобранных по лексемам: тело из меньшего числа лексем
+// (вместе со скобками) слишком тривиально
+const cloneMinFunctionTokens = 12
+
+// Длина k-граммы лексем, которая заменяет поддерево у языков без разбора AST
+const cloneTokenGram = 4
+
+// Поддеревья меньше этого числа узлов не учитываются при сравнении
+const cloneMinSubtreeSize = 3
+
+// Порог схожести, начиная с которого пара функций считается клоном
+const cloneSimilarityThreshold = 70.0
+
+// FunctionFingerprint структурный отпечаток тела функции
+type FunctionFingerprint struct {
+	Name     string
+	BodyHash uint64         // хеш всего нормализованного тела
+	Subtrees map[uint64]int // хеши поддеревьев и их количество
+	Size     int            // число узлов AST в теле (без AST - число лексем)
+	Location SourceLocation // начало объявления функции
+}
+
+// FunctionClone пара структурно похожих функций из двух проектов
+type FunctionClone struct {
+	Function1  string
+	Function2  string
+	Similarity float64
+	Exact      bool // тела совпадают с точностью до имен и литералов
+	Location1  SourceLocation
+	Location2  SourceLocation
+}
+
+// subtreeFrame узел, для которого еще считаются хеши потомков
+type subtreeFrame struct {
+	kind     string
+	children []uint64
+	size     int
+}
+
+// hashSubtrees вычисляет хеши всех поддеревьев узла.
+// Имена и значения литералов не участвуют в хеше (goNodeKind возвращает только тип узла),
+// поэтому переименованные копии дают те же хеши.
+func hashSubtrees(root ast.Node) (uint64, map[uint64]int, int) {
+	subtrees := make(map[uint64]int)
+	var stack []*subtreeFrame
+	var rootHash uint64
+	rootSize := 0
+
+	ast.Inspect(root, func(n ast.Node) bool {
+		if n != nil {
+			stack = append(stack, &subtreeFrame{kind: goNodeKind(n), size: 1})
+			return true
+		}
+
+		frame := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		h := fnv.New64a()
+		h.Write([]byte(frame.kind))
+		buf := make([]byte, 8)
+		for _, child := range frame.children {
+			binary.LittleEndian.PutUint64(buf, child)
+			h.Write(buf)
+		}
+		hash := h.Sum64()
+
+		if frame.size >= cloneMinSubtreeSize {
+			subtrees[hash]++
+		}
+
+		if len(stack) == 0 {
+			rootHash, rootSize = hash, frame.size
+		} else {
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, hash)
+			parent.size += frame.size
+		}
+		return true
+	})
+
+	return rootHash, subtrees, rootSize
+}
+
+// goFunctionFingerprints строит отпечатки тел всех функций файла
+func goFunctionFingerprints(fset *token.FileSet, file *ast.File) []FunctionFingerprint {
+	var fingerprints []FunctionFingerprint
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		bodyHash, subtrees, size := hashSubtrees(fn.Body)
+		if size < cloneMinFunctionSize {
+			continue
+		}
+		pos := fset.Position(fn.Pos())
+		fingerprints = append(fingerprints, FunctionFingerprint{
+			Name:     goFuncName(fn),
+			BodyHash: bodyHash,
+			Subtrees: subtrees,
+			Size:     size,
+			Location: SourceLocation{File: pos.Filename, Line: pos.Line, Column: pos.Column},
+		})
+	}
+	return fingerprints
+}
+
+// tokenFunctionFingerprint строит отпечаток тела функции по лексемам для языков
+// без разбора AST. Поддеревья заменяют k-граммы нормализованных лексем, поэтому
+// переименованная копия дает тот же отпечаток. Для тривиальных функций
+// возвращает false.
+func tokenFunctionFingerprint(name string, body []Token, start SourcePos) (FunctionFingerprint, bool) {
+	if len(body) < cloneMinFunctionTokens {
+		return FunctionFingerprint{}, false
+	}
+	normalized := make([]string, len(body))
+	for i, token := range body {
+		normalized[i] = token.Normalized()
+	}
+	subtrees := make(map[uint64]int)
+	for _, hash := range kGramHashes(normalized, cloneTokenGram) {
+		subtrees[hash]++
+	}
+	bodyHash := kGramHashes(normalized, len(normalized))[0]
+	return FunctionFingerprint{
+		Name:     name,
+		BodyHash: bodyHash,
+		Subtrees: subtrees,
+		Size:     len(body),
+		Location: SourceLocation{Line: start.Line, Column: start.Column},
+	}, true
+}
+
+// functionSimilarity сравнивает две функции по общим поддеревьям (коэффициент Дайса)
+func functionSimilarity(f1, f2 FunctionFingerprint) float64 {
+	if f1.BodyHash == f2.BodyHash {
+		return 100
+	}
+
+	total1, total2, common := 0, 0, 0
+	for hash, count1 := range f1.Subtrees {
+		total1 += count1
+		common += min(count1, f2.Subtrees[hash])
+	}
+	for _, count2 := range f2.Subtrees {
+		total2 += count2
+	}
+	if total1+total2 == 0 {
+		return 0
+	}
+	return float64(2*common) / float64(total1+total2) * 100
+}
+
+// bestFunctionMatches для каждой функции из funcs1 находит наиболее похожую в funcs2
+func bestFunctionMatches(funcs1, funcs2 []FunctionFingerprint) []FunctionClone {
+	matches := make([]FunctionClone, 0, len(funcs1))
+	for _, f1 := range funcs1 {
+		best := FunctionClone{Function1: f1.Name, Location1: f1.Location}
+		for _, f2 := range funcs2 {
+			similarity := functionSimilarity(f1, f2)
+			if similarity > best.Similarity {
+				best.Function2 = f2.Name
+				best.Location2 = f2.Location
+				best.Similarity = similarity
+				best.Exact = f1.BodyHash == f2.BodyHash
+			}
+		}
+		matches = append(matches, best)
+	}
+	return matches
+}
+
+// detectFunctionClones ищет пары структурно похожих функций независимо от их имен.
+// Возвращает найденные клоны и общую схожесть, взвешенную по размеру функций
+// с обеих сторон.
+func detectFunctionClones(funcs1, funcs2 []FunctionFingerprint) ([]FunctionClone, float64) {
+	forward := bestFunctionMatches(funcs1, funcs2)
+	backward := bestFunctionMatches(funcs2, funcs1)
+
+	var weighted, totalSize float64
+	for i, match := range forward {
+		weighted += match.Similarity * float64(funcs1[i].Size)
+		totalSize += float64(funcs1[i].Size)
+	}
+	for i, match := range backward {
+		weighted += match.Similarity * float64(funcs2[i].Size)
+		totalSize += float64(funcs2[i].Size)
+	}
+
+	var clones []FunctionClone
+	for _, match := range forward {
+		if match.Similarity >= cloneSimilarityThreshold {
+			clones = append(clones, match)
+		}
+	}
+	sort.SliceStable(clones, func(i, j int) bool { return clones[i].Similarity > clones[j].Similarity })
+
+	if totalSize == 0 {
+		return clones, 0
+	}
+	return clones, weighted / totalSize
+}
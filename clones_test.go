@@ -0,0 +1,71 @@
+package main
+
+import "testing"
+
+func TestDetectFunctionClones(t *testing.T) {
+	tests := []struct {
+		language string
+		path     string
+		original string
+		renamed  string // та же функция под другими именами
+		other    string // другая функция того же размера
+	}{
+		{
+			language: "golang",
+			path:     "main.go",
+			original: "package main\n\nfunc sum(xs []int) int {\n\ttotal := 0\n\tfor _, x := range xs {\n\t\tif x > 0 {\n\t\t\ttotal += x\n\t\t}\n\t}\n\treturn total\n}\n",
+			renamed:  "package main\n\nfunc add(items []int) int {\n\tacc := 0\n\tfor _, v := range items {\n\t\tif v > 0 {\n\t\t\tacc += v\n\t\t}\n\t}\n\treturn acc\n}\n",
+			other:    "package main\n\nfunc greet(name string) string {\n\tif name == \"\" {\n\t\tname = \"world\"\n\t}\n\treturn \"hello \" + name\n}\n",
+		},
+		{
+			language: "python",
+			path:     "main.py",
+			original: "def total(xs):\n    s = 0\n    for x in xs:\n        if x > 0:\n            s += x\n    return s\n",
+			renamed:  "def add(items):\n    acc = 0\n    for v in items:\n        if v > 0:\n            acc += v\n    return acc\n",
+			other:    "def greet(name):\n    if not name:\n        name = 'world'\n    print('hello', name)\n    return None\n",
+		},
+		{
+			language: "java",
+			path:     "Main.java",
+			original: "class A {\n  int total(int[] xs) {\n    int s = 0;\n    for (int x : xs) { if (x > 0) { s += x; } }\n    return s;\n  }\n}\n",
+			renamed:  "class B {\n  int add(int[] items) {\n    int acc = 0;\n    for (int v : items) { if (v > 0) { acc += v; } }\n    return acc;\n  }\n}\n",
+			other:    "class C {\n  String greet(String name) {\n    if (name == null) { name = \"world\"; }\n    return \"hello \" + name;\n  }\n}\n",
+		},
+		{
+			language: "ruby",
+			path:     "main.rb",
+			original: "def total(xs)\n  s = 0\n  xs.each do |x|\n    s += x if x > 0\n  end\n  s\nend\n",
+			renamed:  "def add(items)\n  acc = 0\n  items.each do |v|\n    acc += v if v > 0\n  end\n  acc\nend\n",
+			other:    "def greet(name)\n  name = 'world' if name.nil?\n  puts \"hello #{name}\"\n  name\nend\n",
+		},
+	}
+
+	for _, tt := range tests {
+		original := analyzeFile(tt.path, tt.original, tt.language)
+		renamed := analyzeFile(tt.path, tt.renamed, tt.language)
+		other := analyzeFile(tt.path, tt.other, tt.language)
+		if len(original.Bodies) != 1 || len(renamed.Bodies) != 1 || len(other.Bodies) != 1 {
+			t.Errorf("%s: отпечатков тел %d, %d, %d, ожидалось по одному",
+				tt.language, len(original.Bodies), len(renamed.Bodies), len(other.Bodies))
+			continue
+		}
+		if loc := original.Bodies[0].Location; loc.File != tt.path || loc.Line == 0 {
+			t.Errorf("%s: место функции %v", tt.language, loc)
+		}
+
+		clones, similarity := detectFunctionClones(original.Bodies, renamed.Bodies)
+		if len(clones) != 1 || !clones[0].Exact || similarity != 100 {
+			t.Errorf("%s: переименованная копия: клоны %+v, схожесть %.1f", tt.language, clones, similarity)
+		}
+		if clones, similarity := detectFunctionClones(original.Bodies, other.Bodies); len(clones) != 0 || similarity >= cloneSimilarityThreshold {
+			t.Errorf("%s: разные функции: клоны %+v, схожесть %.1f", tt.language, clones, similarity)
+		}
+	}
+}
+
+func TestTokenFunctionFingerprintSkipsTrivial(t *testing.T) {
+	fa, _ := analyzeSourceTokens(tokenize("def get(self):\n    return self.x\n", "python"), "python")
+	if len(fa.Bodies) != 0 {
+		t.Errorf("тривиальная функция дала отпечаток: %+v", fa.Bodies)
+	}
+}
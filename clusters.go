@@ -0,0 +1,313 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Методы поиска кластеров
+const (
+	clusterComponents = "components" // компоненты связности графа пар выше порога
+	clusterModularity = "modularity" // сообщества по модулярности (локальные перемещения Louvain)
+)
+
+// Меньшие группы - это просто пары, они и так есть в таблице результатов
+const clusterMinSize = 3
+
+// PlagiarismCluster группа работ, связанных подозрительными парами
+type PlagiarismCluster struct {
+	Members      []string
+	Edges        []ComparisonResult // пары выше порога внутри группы
+	AverageScore float64            // средняя оценка по всем парам участников
+	LikelySource string
+	SourceReason string
+}
+
+// similarityGraph граф работ: вершины - работы из пар выше порога, ребра - сами пары
+type similarityGraph struct {
+	names []string
+	index map[string]int
+	adj   []map[int]float64
+	edges []ComparisonResult
+}
+
+// buildSimilarityGraph строит граф по парам не ниже порога
+func buildSimilarityGraph(flagged []ComparisonResult) similarityGraph {
+	g := similarityGraph{index: make(map[string]int)}
+	node := func(name string) int {
+		if i, ok := g.index[name]; ok {
+			return i
+		}
+		g.index[name] = len(g.names)
+		g.names = append(g.names, name)
+		g.adj = append(g.adj, make(map[int]float64))
+		return len(g.names) - 1
+	}
+	for _, r := range flagged {
+		i, j := node(r.Project1), node(r.Project2)
+		g.adj[i][j] = r.OverallScore
+		g.adj[j][i] = r.OverallScore
+		g.edges = append(g.edges, r)
+	}
+	return g
+}
+
+// components возвращает номер компоненты связности каждой вершины
+func (g similarityGraph) components() []int {
+	label := make([]int, len(g.names))
+	for i := range label {
+		label[i] = -1
+	}
+	for start := range g.names {
+		if label[start] >= 0 {
+			continue
+		}
+		label[start] = start
+		queue := []int{start}
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			for u := range g.adj[v] {
+				if label[u] < 0 {
+					label[u] = start
+					queue = append(queue, u)
+				}
+			}
+		}
+	}
+	return label
+}
+
+// communities делит граф на сообщества локальными перемещениями вершин, пока
+// модулярность растет (первая фаза алгоритма Louvain). В отличие от компонент
+// связности, разделяет группы, соединенные одной случайной парой.
+func (g similarityGraph) communities() []int {
+	n := len(g.names)
+	label := make([]int, n)
+	degree := make([]float64, n)
+	total := make([]float64, n) // сумма степеней вершин сообщества
+	var doubleWeight float64
+	for i := range g.names {
+		label[i] = i
+		for _, w := range g.adj[i] {
+			degree[i] += w
+		}
+		total[i] = degree[i]
+		doubleWeight += degree[i]
+	}
+	if doubleWeight == 0 {
+		return label
+	}
+
+	for moved := true; moved; {
+		moved = false
+		for i := 0; i < n; i++ {
+			links := make(map[int]float64)
+			for j, w := range g.adj[i] {
+				links[label[j]] += w
+			}
+
+			current := label[i]
+			total[current] -= degree[i]
+
+			// Перебираем сообщества соседей по порядку, чтобы результат не зависел от обхода карты
+			candidates := make([]int, 0, len(links))
+			for c := range links {
+				candidates = append(candidates, c)
+			}
+			sort.Ints(candidates)
+
+			best := current
+			bestGain := links[current] - total[current]*degree[i]/doubleWeight
+			for _, c := range candidates {
+				gain := links[c] - total[c]*degree[i]/doubleWeight
+				if gain > bestGain+1e-9 {
+					best, bestGain = c, gain
+				}
+			}
+
+			total[best] += degree[i]
+			if best != current {
+				label[i] = best
+				moved = true
+			}
+		}
+	}
+	return label
+}
+
+// findClusters ищет группы работ выбранным методом. Средняя оценка считается по
+// всем сравненным парам участников (в том числе ниже порога), несравненные
+// пары считаются несхожими.
+func findClusters(flagged, all []ComparisonResult, method string) []PlagiarismCluster {
+	g := buildSimilarityGraph(flagged)
+	var labels []int
+	if method == clusterModularity {
+		labels = g.communities()
+	} else {
+		labels = g.components()
+	}
+
+	groups := make(map[int][]string)
+	var order []int
+	for i, name := range g.names {
+		if _, seen := groups[labels[i]]; !seen {
+			order = append(order, labels[i])
+		}
+		groups[labels[i]] = append(groups[labels[i]], name)
+	}
+
+	scores := make(map[[2]string]float64)
+	archiveYears := make(map[string]int)
+	for _, r := range all {
+		scores[[2]string{r.Project1, r.Project2}] = r.OverallScore
+		scores[[2]string{r.Project2, r.Project1}] = r.OverallScore
+		if r.ArchiveYear != 0 {
+			// Архивная работа всегда вторая в паре: архив добавляется после текущих работ
+			archiveYears[r.Project2] = r.ArchiveYear
+		}
+	}
+
+	var clusters []PlagiarismCluster
+	for _, label := range order {
+		members := groups[label]
+		if len(members) < clusterMinSize {
+			continue
+		}
+		sort.Strings(members)
+
+		cluster := PlagiarismCluster{Members: members}
+		strength := make(map[string]float64) // сумма оценок работы с остальными участниками
+		inCluster := make(map[string]bool)
+		for _, m := range members {
+			inCluster[m] = true
+		}
+		for _, e := range g.edges {
+			if inCluster[e.Project1] && inCluster[e.Project2] {
+				cluster.Edges = append(cluster.Edges, e)
+			}
+		}
+
+		var sum float64
+		pairs := 0
+		for a := 0; a < len(members); a++ {
+			for b := a + 1; b < len(members); b++ {
+				score := scores[[2]string{members[a], members[b]}]
+				sum += score
+				pairs++
+				strength[members[a]] += score
+				strength[members[b]] += score
+			}
+		}
+		cluster.AverageScore = sum / float64(pairs)
+		cluster.LikelySource, cluster.SourceReason = likelySource(members, strength, archiveYears)
+		clusters = append(clusters, cluster)
+	}
+
+	sort.SliceStable(clusters, func(i, j int) bool {
+		if len(clusters[i].Members) != len(clusters[j].Members) {
+			return len(clusters[i].Members) > len(clusters[j].Members)
+		}
+		return clusters[i].AverageScore > clusters[j].AverageScore
+	})
+	return clusters
+}
+
+// likelySource выбирает вероятный первоисточник группы: самую старую работу из
+// архива, а если таких нет - работу, сильнее всего похожую на остальных участников
+func likelySource(members []string, strength map[string]float64, archiveYears map[string]int) (string, string) {
+	source, year := "", 0
+	for _, m := range members {
+		if y := archiveYears[m]; y != 0 && (year == 0 || y < year) {
+			source, year = m, y
+		}
+	}
+	if source != "" {
+		return source, fmt.Sprintf("самая ранняя работа группы (архив %d года)", year)
+	}
+
+	best := -1.0
+	for _, m := range members {
+		if strength[m] > best {
+			source, best = m, strength[m]
+		}
+	}
+	return source, "больше всего похожа на остальных участников"
+}
+
+// Размеры SVG-графа кластера
+const (
+	clusterGraphSize   = 360
+	clusterGraphRadius = 120
+)
+
+// clusterGraphNode вершина SVG-графа
+type clusterGraphNode struct {
+	X, Y   float64
+	Name   string
+	Source bool
+	Anchor string // выравнивание подписи
+	LabelX float64
+	LabelY float64
+}
+
+// clusterGraphEdge ребро SVG-графа
+type clusterGraphEdge struct {
+	X1, Y1, X2, Y2 float64
+	Width          float64
+	Color          string
+	Title          string
+}
+
+// clusterView кластер для HTML-отчета вместе с раскладкой графа
+type clusterView struct {
+	Number int
+	PlagiarismCluster
+	Size  int
+	Nodes []clusterGraphNode
+	Lines []clusterGraphEdge
+}
+
+// clusterViews раскладывает участников каждого кластера по окружности
+func clusterViews(clusters []PlagiarismCluster, scoring ScoringConfig) []clusterView {
+	colors := map[string]string{bandHigh: "#dc3545", bandMedium: "#ffc107", bandLow: "#28a745"}
+
+	var views []clusterView
+	for n, c := range clusters {
+		view := clusterView{Number: n + 1, PlagiarismCluster: c, Size: clusterGraphSize}
+		center := float64(clusterGraphSize) / 2
+		positions := make(map[string]clusterGraphNode)
+		for i, name := range c.Members {
+			angle := 2*math.Pi*float64(i)/float64(len(c.Members)) - math.Pi/2
+			node := clusterGraphNode{
+				X:      center + clusterGraphRadius*math.Cos(angle),
+				Y:      center + clusterGraphRadius*math.Sin(angle),
+				Name:   name,
+				Source: name == c.LikelySource,
+				Anchor: "middle",
+			}
+			// Подпись выносится наружу окружности
+			node.LabelX = center + (clusterGraphRadius+18)*math.Cos(angle)
+			node.LabelY = center + (clusterGraphRadius+18)*math.Sin(angle) + 4
+			if math.Cos(angle) > 0.3 {
+				node.Anchor = "start"
+			} else if math.Cos(angle) < -0.3 {
+				node.Anchor = "end"
+			}
+			positions[name] = node
+			view.Nodes = append(view.Nodes, node)
+		}
+		for _, e := range c.Edges {
+			a, b := positions[e.Project1], positions[e.Project2]
+			view.Lines = append(view.Lines, clusterGraphEdge{
+				X1: a.X, Y1: a.Y, X2: b.X, Y2: b.Y,
+				Width: 1 + 4*math.Max(0, e.OverallScore-scoring.threshold(e))/math.Max(1, 100-scoring.threshold(e)),
+				Color: colors[scoring.band(e.OverallScore)],
+				Title: fmt.Sprintf("%s ~ %s: %.2f%%", e.Project1, e.Project2, e.OverallScore),
+			})
+		}
+		views = append(views, view)
+	}
+	return views
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// pair результат сравнения двух работ с общей оценкой score
+func pair(p1, p2 string, score float64) ComparisonResult {
+	return ComparisonResult{Project1: p1, Project2: p2, OverallScore: score}
+}
+
+func TestFindClusters(t *testing.T) {
+	// Два треугольника, связанные одной парой c-d, и отдельная пара g-h
+	flagged := []ComparisonResult{
+		pair("a", "b", 90), pair("b", "c", 90), pair("a", "c", 90),
+		pair("d", "e", 80), pair("e", "f", 80), pair("d", "f", 80),
+		pair("c", "d", 55),
+		pair("g", "h", 95),
+	}
+
+	tests := []struct {
+		method string
+		want   [][]string
+	}{
+		{clusterComponents, [][]string{{"a", "b", "c", "d", "e", "f"}}},
+		{clusterModularity, [][]string{{"a", "b", "c"}, {"d", "e", "f"}}},
+	}
+	for _, tt := range tests {
+		clusters := findClusters(flagged, flagged, tt.method)
+		var got [][]string
+		for _, c := range clusters {
+			got = append(got, c.Members)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: группы %v, ожидалось %v", tt.method, got, tt.want)
+		}
+	}
+
+	clusters := findClusters(flagged, flagged, clusterModularity)
+	if len(clusters) == 2 {
+		if clusters[0].AverageScore != 90 || clusters[1].AverageScore != 80 {
+			t.Errorf("средние оценки %.1f и %.1f, ожидалось 90 и 80", clusters[0].AverageScore, clusters[1].AverageScore)
+		}
+		if len(clusters[0].Edges) != 3 {
+			t.Errorf("ребер в группе %d, ожидалось 3", len(clusters[0].Edges))
+		}
+	}
+}
+
+func TestFindClustersAverageUsesAllPairs(t *testing.T) {
+	// Пара a-c ниже порога, но сравнена; пара c-d не сравнивалась и считается нулем
+	flagged := []ComparisonResult{pair("a", "b", 90), pair("b", "c", 90), pair("c", "d", 90)}
+	all := append([]ComparisonResult{pair("a", "c", 30), pair("a", "d", 0), pair("b", "d", 30)}, flagged...)
+
+	clusters := findClusters(flagged, all, clusterComponents)
+	if len(clusters) != 1 {
+		t.Fatalf("групп %d, ожидалась одна", len(clusters))
+	}
+	if want := (90 + 90 + 90 + 30 + 0 + 30) / 6.0; clusters[0].AverageScore != want {
+		t.Errorf("средняя оценка %.2f, ожидалось %.2f", clusters[0].AverageScore, want)
+	}
+}
+
+func TestLikelySource(t *testing.T) {
+	members := []string{"a", "b", "c"}
+	strength := map[string]float64{"a": 100, "b": 180, "c": 120}
+
+	if source, _ := likelySource(members, strength, nil); source != "b" {
+		t.Errorf("источник %s, ожидалась работа b, сильнее всего похожая на остальных", source)
+	}
+	archive := map[string]int{"a": 2023, "c": 2021}
+	if source, reason := likelySource(members, strength, archive); source != "c" || reason == "" {
+		t.Errorf("источник %s (%s), ожидалась самая ранняя архивная работа c", source, reason)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+// Фрагмент, который есть только у двух работ, никогда не считается общим:
+// иначе подавлялось бы само списывание
+const commonMinProjects = 3
+
+// commonCodeHashes находит отпечатки, которые встречаются более чем в доле fraction
+// всех работ (например, обязательный main с разбором ввода)
+func commonCodeHashes(projects []Project, fraction float64) map[uint64]bool {
+	common := make(map[uint64]bool)
+	if fraction <= 0 || len(projects) < commonMinProjects {
+		return common
+	}
+
+	frequency := make(map[uint64]int)
+	for _, p := range projects {
+		seen := make(map[uint64]bool)
+		for _, fp := range p.Fingerprints {
+			if !seen[fp.Hash] {
+				seen[fp.Hash] = true
+				frequency[fp.Hash]++
+			}
+		}
+	}
+
+	limit := fraction * float64(len(projects))
+	for hash, count := range frequency {
+		if float64(count) > limit && count >= commonMinProjects {
+			common[hash] = true
+		}
+	}
+	return common
+}
+
+// suppressCommonCode исключает общие для когорты фрагменты из всех работ тем же
+// способом, что и базовый код. Возвращает количество подавленных отпечатков.
+func suppressCommonCode(projects []Project, fraction float64) int {
+	hashes := commonCodeHashes(projects, fraction)
+	for i := range projects {
+		p := &projects[i]
+		total := len(p.Fingerprints)
+		removed := excludeCode(p, hashes)
+		if total > 0 {
+			p.CommonRemoved = float64(removed) / float64(total) * 100
+		}
+		p.Available = detectAvailableMetrics(p.SourceFile)
+	}
+	return len(hashes)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// cohort строит n работ; hash[i] попадает в первые share[i] из них
+func cohort(n int, shares map[uint64]int) []Project {
+	projects := make([]Project, n)
+	for i := range projects {
+		projects[i].Name = fmt.Sprintf("student%d", i)
+		for hash, share := range shares {
+			if i < share {
+				// Повтор внутри одной работы не должен увеличивать частоту
+				projects[i].Fingerprints = append(projects[i].Fingerprints,
+					Fingerprint{Hash: hash}, Fingerprint{Hash: hash})
+			}
+		}
+	}
+	return projects
+}
+
+func TestCommonCodeHashes(t *testing.T) {
+	tests := []struct {
+		name     string
+		projects int
+		share    int
+		fraction float64
+		want     bool
+	}{
+		{"больше порога", 10, 6, 0.5, true},
+		{"ровно на пороге", 10, 5, 0.5, false},
+		{"чуть меньше порога", 10, 4, 0.5, false},
+		{"у всех работ", 10, 10, 0.9, true},
+		{"подавление выключено", 10, 10, 0, false},
+		{"слишком маленькая когорта", 2, 2, 0.5, false},
+		{"общий фрагмент двух работ", 3, 2, 0.3, false},
+		{"минимальная когорта", 3, 3, 0.5, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const hash = 42
+			common := commonCodeHashes(cohort(tt.projects, map[uint64]int{hash: tt.share}), tt.fraction)
+			if common[hash] != tt.want {
+				t.Errorf("отпечаток в %d из %d работ при доле %.2f: общий = %v, ожидалось %v",
+					tt.share, tt.projects, tt.fraction, common[hash], tt.want)
+			}
+		})
+	}
+}
+
+func TestSuppressCommonCode(t *testing.T) {
+	projects := cohort(10, map[uint64]int{1: 10, 2: 4})
+	if n := suppressCommonCode(projects, 0.5); n != 1 {
+		t.Fatalf("подавлено %d отпечатков, ожидался 1", n)
+	}
+	for i, p := range projects {
+		kept := 0
+		for _, fp := range p.Fingerprints {
+			if fp.Hash == 1 {
+				t.Fatalf("%s: общий отпечаток не подавлен", p.Name)
+			}
+			kept++
+		}
+		want := 0
+		if i < 4 {
+			want = 2
+		}
+		if kept != want {
+			t.Errorf("%s: осталось %d отпечатков, ожидалось %d", p.Name, kept, want)
+		}
+	}
+}
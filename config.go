@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Config хранит настройки текущего запуска
+type Config struct {
+	InputDir       string   // папка с работами студентов
+	OutputDir      string   // папка для отчетов
+	Languages      []string // анализируемые языки (пусто - все поддерживаемые)
+	Formats        []string // форматы отчетов
+	Fingerprint    FingerprintConfig
+	Algorithm      string  // алгоритм структурного сравнения: winnowing, gst или both
+	MinMatchLength int     // минимальная длина совпадения для Greedy String Tiling
+	BaseDir        string  // папка с шаблоном задания, который вычитается из работ
+	CommonFraction float64 // доля работ, начиная с которой фрагмент считается общим (0 - не подавлять)
+	Scoring        ScoringConfig
+	Workers        int               // число потоков сравнения пар
+	MinShared      int               // минимум общих отпечатков (у пар на разных языках - k-грамм IR), чтобы пара с архивной работой сравнивалась (0 - сравнивать все)
+	Extensions     map[string]string // расширение файла -> язык
+	Ignore         []string          // шаблоны путей, которые не анализируются
+	CorpusDir      string            // папка архива работ прошлых лет (пусто - без архива)
+	Course         string            // курс, под которым работы хранятся в архиве
+	Year           int               // год, под которым работы текущего запуска попадают в архив
+	CacheDir       string            // папка кэша анализа файлов и сравнений пар (пусто - без кэша)
+	ClusterMethod  string            // способ поиска групп списывания: components или modularity
+	CrossLanguage  bool              // сравнивать работы на разных языках по промежуточному представлению
+	MergeRootFiles bool              // файл alice.py в корне входной папки - часть работы alice/, а не отдельная работа
+	ConfigFile     string            // файл конфигурации курса
+	Assignment     string            // задание из файла конфигурации
+}
+
+// Алгоритмы структурного сравнения кода
+const (
+	algorithmWinnowing = "winnowing"
+	algorithmGST       = "gst"
+	algorithmBoth      = "both"
+)
+
+// Форматы отчетов
+const (
+	formatHTML = "html"
+	formatJSON = "json"
+	formatCSV  = "csv"
+)
+
+// reportFormats перечисляет поддерживаемые форматы отчетов
+var reportFormats = []string{formatHTML, formatJSON, formatCSV}
+
+// FingerprintConfig параметры построения отпечатков winnowing
+type FingerprintConfig struct {
+	K      int // длина k-граммы в токенах
+	Window int // размер окна winnowing
+}
+
+// config - настройки, с которыми работают анализаторы и сравнение
+var config = defaultConfig()
+
+// defaultConfig возвращает настройки по умолчанию
+func defaultConfig() Config {
+	return Config{
+		InputDir:  "./projects",
+		OutputDir: "./reports",
+		Formats:   []string{formatHTML},
+		Fingerprint: FingerprintConfig{
+			K:      7,
+			Window: 5,
+		},
+		Algorithm:      algorithmWinnowing,
+		MinMatchLength: 9,
+		Scoring:        defaultScoringConfig(),
+		Workers:        runtime.NumCPU(),
+		MinShared:      1,
+		Year:           time.Now().Year(),
+		ClusterMethod:  clusterComponents,
+		Extensions:     supportedExtensions,
+	}
+}
+
+// validate проверяет корректность настроек
+func (c Config) validate() error {
+	if c.Fingerprint.K < 1 {
+		return fmt.Errorf("длина k-граммы должна быть не меньше 1, получено %d", c.Fingerprint.K)
+	}
+	if c.Fingerprint.Window < 1 {
+		return fmt.Errorf("размер окна winnowing должен быть не меньше 1, получено %d", c.Fingerprint.Window)
+	}
+	switch c.Algorithm {
+	case algorithmWinnowing, algorithmGST, algorithmBoth:
+	default:
+		return fmt.Errorf("неизвестный алгоритм сравнения %q (допустимо: %s, %s, %s)",
+			c.Algorithm, algorithmWinnowing, algorithmGST, algorithmBoth)
+	}
+	for _, lang := range c.Languages {
+		if !isSupportedLanguage(lang) {
+			return fmt.Errorf("неподдерживаемый язык %q", lang)
+		}
+	}
+	if c.Workers < 1 {
+		return fmt.Errorf("число потоков должно быть не меньше 1, получено %d", c.Workers)
+	}
+	if c.MinShared < 0 {
+		return fmt.Errorf("минимум общих отпечатков не может быть отрицательным, получено %d", c.MinShared)
+	}
+	if c.CorpusDir != "" && c.Year < 1 {
+		return fmt.Errorf("год для архива должен быть положительным, получено %d", c.Year)
+	}
+	switch c.ClusterMethod {
+	case clusterComponents, clusterModularity:
+	default:
+		return fmt.Errorf("неизвестный способ поиска кластеров %q (допустимо: %s, %s)",
+			c.ClusterMethod, clusterComponents, clusterModularity)
+	}
+	if len(c.Extensions) == 0 {
+		return fmt.Errorf("не задано ни одного расширения файлов")
+	}
+	if len(c.Formats) == 0 {
+		return fmt.Errorf("не задан ни один формат отчета")
+	}
+	for _, format := range c.Formats {
+		if !containsString(reportFormats, format) {
+			return fmt.Errorf("неизвестный формат отчета %q (допустимо: %s)", format, strings.Join(reportFormats, ", "))
+		}
+	}
+	if c.CommonFraction < 0 || c.CommonFraction > 1 {
+		return fmt.Errorf("доля общих фрагментов должна быть от 0 до 1, получено %g", c.CommonFraction)
+	}
+	if c.MinMatchLength < 1 {
+		return fmt.Errorf("минимальная длина совпадения должна быть не меньше 1, получено %d", c.MinMatchLength)
+	}
+	return c.Scoring.validate()
+}
+
+// languageEnabled сообщает, анализируется ли язык в этом запуске
+func (c Config) languageEnabled(lang string) bool {
+	return len(c.Languages) == 0 || containsString(c.Languages, lang)
+}
+
+// isSupportedLanguage проверяет, есть ли язык среди supportedExtensions
+func isSupportedLanguage(lang string) bool {
+	for _, supported := range supportedExtensions {
+		if supported == lang {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString проверяет наличие строки в списке
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList разбирает список значений через запятую
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// usesWinnowing сообщает, считаются ли отпечатки winnowing в этом запуске
+func (c Config) usesWinnowing() bool {
+	return c.Algorithm == algorithmWinnowing || c.Algorithm == algorithmBoth
+}
+
+// usesGST сообщает, запускается ли Greedy String Tiling в этом запуске
+func (c Config) usesGST() bool {
+	return c.Algorithm == algorithmGST || c.Algorithm == algorithmBoth
+}
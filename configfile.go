@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Файл настроек, который подхватывается из текущей папки, если -config не указан
+const defaultConfigFile = "plagiarism.json"
+
+// fileSettings настройки курса или задания в файле конфигурации.
+// Незаданные поля не меняют текущие настройки.
+type fileSettings struct {
+	Input          *string            `json:"input"`
+	Output         *string            `json:"output"`
+	Extensions     map[string]string  `json:"extensions"` // расширение -> язык; пустой язык отключает расширение
+	Languages      []string           `json:"languages"`
+	Formats        []string           `json:"formats"`
+	Ignore         []string           `json:"ignore"` // добавляются к шаблонам уровнем выше
+	K              *int               `json:"k"`
+	Window         *int               `json:"window"`
+	Algorithm      *string            `json:"algorithm"`
+	MinMatch       *int               `json:"min_match"`
+	Base           *string            `json:"base"`
+	Common         *float64           `json:"common"`
+	Weights        map[string]float64 `json:"weights"` // заменяют веса только перечисленных метрик
+	Threshold      *float64           `json:"threshold"`
+	Cross          *bool              `json:"cross_language"`
+	MergeRootFiles *bool              `json:"merge_root_files"`
+	CrossThreshold *float64           `json:"cross_threshold"`
+	High           *float64           `json:"high"`
+	Medium         *float64           `json:"medium"`
+	Workers        *int               `json:"workers"`
+	MinShared      *int               `json:"min_shared"`
+	Corpus         *string            `json:"corpus"`
+	Course         *string            `json:"course"`
+	Year           *int               `json:"year"`
+	Cache          *string            `json:"cache"`
+	Clusters       *string            `json:"clusters"`
+}
+
+// configFile содержимое файла конфигурации: настройки курса и
+// переопределения для отдельных заданий
+type configFile struct {
+	fileSettings
+	Assignments map[string]fileSettings `json:"assignments"`
+}
+
+// loadConfigFile накладывает на c настройки из файла c.ConfigFile и, если задано
+// c.Assignment, переопределения этого задания. Относительные пути в файле
+// отсчитываются от папки, где он лежит.
+func loadConfigFile(c *Config) error {
+	file := c.ConfigFile
+	if file == "" {
+		if _, err := os.Stat(defaultConfigFile); err != nil {
+			if c.Assignment != "" {
+				return fmt.Errorf("задание %q указано без файла конфигурации", c.Assignment)
+			}
+			return nil
+		}
+		file = defaultConfigFile
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("чтение файла конфигурации: %w", err)
+	}
+
+	var parsed configFile
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&parsed); err != nil {
+		return fmt.Errorf("разбор %s: %w", file, err)
+	}
+
+	root := filepath.Dir(file)
+	if err := parsed.fileSettings.apply(c, root); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	if c.Assignment == "" {
+		return nil
+	}
+	assignment, ok := parsed.Assignments[c.Assignment]
+	if !ok {
+		var names []string
+		for name := range parsed.Assignments {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("в %s нет задания %q (есть: %s)", file, c.Assignment, strings.Join(names, ", "))
+	}
+	if err := assignment.apply(c, root); err != nil {
+		return fmt.Errorf("%s, задание %s: %w", file, c.Assignment, err)
+	}
+	return nil
+}
+
+// apply переносит заданные поля в настройки запуска
+func (s fileSettings) apply(c *Config, root string) error {
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(root, p)
+	}
+
+	if s.Input != nil {
+		c.InputDir = resolve(*s.Input)
+	}
+	if s.Output != nil {
+		c.OutputDir = resolve(*s.Output)
+	}
+	if s.Base != nil {
+		c.BaseDir = resolve(*s.Base)
+	}
+	if s.Corpus != nil {
+		c.CorpusDir = resolve(*s.Corpus)
+	}
+	if s.Cache != nil {
+		c.CacheDir = resolve(*s.Cache)
+	}
+	if s.Course != nil {
+		c.Course = *s.Course
+	}
+	if s.Year != nil {
+		c.Year = *s.Year
+	}
+
+	if len(s.Extensions) > 0 {
+		// Копия, чтобы не менять карту, общую с настройками уровнем выше
+		extensions := make(map[string]string, len(c.Extensions))
+		for ext, lang := range c.Extensions {
+			extensions[ext] = lang
+		}
+		for ext, lang := range s.Extensions {
+			ext = strings.ToLower(ext)
+			if !strings.HasPrefix(ext, ".") {
+				ext = "." + ext
+			}
+			if lang == "" {
+				delete(extensions, ext)
+				continue
+			}
+			if !isSupportedLanguage(lang) {
+				return fmt.Errorf("расширение %s: неподдерживаемый язык %q", ext, lang)
+			}
+			extensions[ext] = lang
+		}
+		c.Extensions = extensions
+	}
+
+	if s.Languages != nil {
+		c.Languages = s.Languages
+	}
+	if s.Formats != nil {
+		c.Formats = s.Formats
+	}
+	for _, pattern := range s.Ignore {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("некорректный шаблон исключения %q", pattern)
+		}
+		c.Ignore = append(c.Ignore[:len(c.Ignore):len(c.Ignore)], pattern)
+	}
+
+	if s.K != nil {
+		c.Fingerprint.K = *s.K
+	}
+	if s.Window != nil {
+		c.Fingerprint.Window = *s.Window
+	}
+	if s.Algorithm != nil {
+		c.Algorithm = *s.Algorithm
+	}
+	if s.MinMatch != nil {
+		c.MinMatchLength = *s.MinMatch
+	}
+	if s.Common != nil {
+		c.CommonFraction = *s.Common
+	}
+
+	if len(s.Weights) > 0 {
+		weights := make(map[string]float64, len(c.Scoring.Weights))
+		for metric, weight := range c.Scoring.Weights {
+			weights[metric] = weight
+		}
+		for metric, weight := range s.Weights {
+			weights[metric] = weight
+		}
+		c.Scoring.Weights = weights
+	}
+	if s.Threshold != nil {
+		c.Scoring.Threshold = *s.Threshold
+	}
+	if s.Cross != nil {
+		c.CrossLanguage = *s.Cross
+	}
+	if s.MergeRootFiles != nil {
+		c.MergeRootFiles = *s.MergeRootFiles
+	}
+	if s.CrossThreshold != nil {
+		c.Scoring.CrossThreshold = *s.CrossThreshold
+	}
+	if s.High != nil {
+		c.Scoring.HighBand = *s.High
+	}
+	if s.Medium != nil {
+		c.Scoring.MediumBand = *s.Medium
+	}
+	if s.Workers != nil {
+		c.Workers = *s.Workers
+	}
+	if s.MinShared != nil {
+		c.MinShared = *s.MinShared
+	}
+	if s.Clusters != nil {
+		c.ClusterMethod = *s.Clusters
+	}
+	return nil
+}
+
+// fileLanguage определяет язык файла по расширению с учетом настроек запуска.
+// Возвращает false для файлов, которые не анализируются.
+func (c Config) fileLanguage(path string) (string, bool) {
+	lang, ok := c.Extensions[strings.ToLower(filepath.Ext(path))]
+	if !ok || !c.languageEnabled(lang) {
+		return "", false
+	}
+	return lang, true
+}
+
+// ignored проверяет путь относительно корня обхода по шаблонам исключения.
+// Шаблон сравнивается со всем путем и с каждой его частью, так что "vendor"
+// исключает любую папку vendor, а "*_test.go" - тесты на любой глубине.
+func (c Config) ignored(rel string) bool {
+	rel = filepath.ToSlash(rel)
+	if rel == "." || rel == "" {
+		return false
+	}
+	for _, pattern := range c.Ignore {
+		if matched, _ := path.Match(pattern, rel); matched {
+			return true
+		}
+		for _, part := range strings.Split(rel, "/") {
+			if matched, _ := path.Match(pattern, part); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
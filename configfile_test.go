@@ -0,0 +1,167 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const courseConfig = `{
+	"input": "works",
+	"window": 6,
+	"threshold": 30,
+	"ignore": ["vendor"],
+	"weights": {"text": 1},
+	"extensions": {"h": "c"},
+	"assignments": {
+		"lab2": {
+			"k": 4,
+			"base": "templates/lab2",
+			"threshold": 45,
+			"ignore": ["*_test.go"],
+			"weights": {"ast": 5},
+			"extensions": {".h": ""}
+		}
+	}
+}`
+
+// writeConfig сохраняет файл конфигурации во временную папку
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "plagiarism.json")
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	file := writeConfig(t, courseConfig)
+	root := filepath.Dir(file)
+	defaults := defaultConfig()
+
+	c := defaultConfig()
+	c.ConfigFile = file
+	if err := loadConfigFile(&c); err != nil {
+		t.Fatal(err)
+	}
+	if c.InputDir != filepath.Join(root, "works") || c.Fingerprint.Window != 6 || c.Fingerprint.K != defaults.Fingerprint.K {
+		t.Errorf("настройки курса: input %q, window %d, k %d", c.InputDir, c.Fingerprint.Window, c.Fingerprint.K)
+	}
+	if c.Scoring.Threshold != 30 || c.Extensions[".h"] != "c" || !reflect.DeepEqual(c.Ignore, append(defaults.Ignore, "vendor")) {
+		t.Errorf("настройки курса: threshold %g, .h %q, ignore %v", c.Scoring.Threshold, c.Extensions[".h"], c.Ignore)
+	}
+
+	lab := defaultConfig()
+	lab.ConfigFile, lab.Assignment = file, "lab2"
+	if err := loadConfigFile(&lab); err != nil {
+		t.Fatal(err)
+	}
+	if lab.Fingerprint.K != 4 || lab.Fingerprint.Window != 6 || lab.Scoring.Threshold != 45 {
+		t.Errorf("задание: k %d, window %d, threshold %g", lab.Fingerprint.K, lab.Fingerprint.Window, lab.Scoring.Threshold)
+	}
+	if lab.BaseDir != filepath.Join(root, "templates", "lab2") {
+		t.Errorf("шаблон задания %q не отсчитан от папки файла", lab.BaseDir)
+	}
+	if want := append(defaults.Ignore, "vendor", "*_test.go"); !reflect.DeepEqual(lab.Ignore, want) {
+		t.Errorf("исключения задания %v, ожидалось %v", lab.Ignore, want)
+	}
+	if lab.Scoring.Weights["text"] != 1 || lab.Scoring.Weights["ast"] != 5 ||
+		lab.Scoring.Weights["tokens"] != defaults.Scoring.Weights["tokens"] {
+		t.Errorf("веса задания %v", lab.Scoring.Weights)
+	}
+	if _, ok := lab.Extensions[".h"]; ok {
+		t.Error("задание не отключило расширение .h")
+	}
+	if !reflect.DeepEqual(defaults.Scoring.Weights, defaultConfig().Scoring.Weights) || defaultConfig().Extensions[".h"] != "" {
+		t.Error("файл конфигурации изменил настройки по умолчанию")
+	}
+}
+
+func TestLoadConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		assignment string
+		want       string
+	}{
+		{"неизвестный ключ", `{"treshold": 30}`, "", "unknown field"},
+		{"неизвестный ключ задания", `{"assignments": {"lab1": {"kk": 3}}}`, "lab1", "unknown field"},
+		{"битый JSON", `{"k": 5,}`, "", "разбор"},
+		{"неверный тип", `{"k": "five"}`, "", "разбор"},
+		{"нет задания", `{"assignments": {"lab1": {}, "lab3": {}}}`, "lab2", "есть: lab1, lab3"},
+		{"неподдерживаемый язык", `{"extensions": {".kt": "kotlin"}}`, "", "kotlin"},
+		{"некорректный шаблон", `{"ignore": ["[a-"]}`, "", "шаблон исключения"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := defaultConfig()
+			c.ConfigFile, c.Assignment = writeConfig(t, tt.content), tt.assignment
+			err := loadConfigFile(&c)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ошибка %v, ожидалась содержащая %q", err, tt.want)
+			}
+		})
+	}
+
+	c := defaultConfig()
+	c.ConfigFile = filepath.Join(t.TempDir(), "missing.json")
+	if err := loadConfigFile(&c); err == nil {
+		t.Error("отсутствующий файл не вызвал ошибку")
+	}
+}
+
+func TestConfigFileAndFlags(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+	file := writeConfig(t, courseConfig)
+
+	tests := []struct {
+		name  string
+		args  []string
+		ok    bool
+		check func(Config) bool
+	}{
+		{
+			name: "флаг важнее задания и курса",
+			args: []string{"-config", file, "-assignment", "lab2", "-k", "9", "-window", "3"},
+			ok:   true,
+			check: func(c Config) bool {
+				return c.Fingerprint.K == 9 && c.Fingerprint.Window == 3 && c.Scoring.Threshold == 45
+			},
+		},
+		{
+			name: "веса флага дополняют веса из файла",
+			args: []string{"-config", file, "-assignment", "lab2", "-weights", "ast=2,tokens=0"},
+			ok:   true,
+			check: func(c Config) bool {
+				return c.Scoring.Weights["text"] == 1 && c.Scoring.Weights["ast"] == 2 && c.Scoring.Weights["tokens"] == 0
+			},
+		},
+		{
+			name: "порядок флагов не важен",
+			args: []string{"-threshold", "10", "-config", file},
+			ok:   true,
+			check: func(c Config) bool {
+				return c.Scoring.Threshold == 10 && c.Fingerprint.Window == 6
+			},
+		},
+		{name: "неизвестное задание", args: []string{"-config", file, "-assignment", "lab9"}},
+		{name: "задание без файла", args: []string{"-assignment", "lab2"}},
+		{name: "битый файл", args: []string{"-config", writeConfig(t, `{"k": }`)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config = defaultConfig()
+			_, ok := parseFlags("scan", tt.args, 0, configuredBase)
+			if ok != tt.ok {
+				t.Fatalf("разбор %v: ok = %v, ожидалось %v", tt.args, ok, tt.ok)
+			}
+			if ok && !tt.check(config) {
+				t.Errorf("настройки после разбора %v: %+v", tt.args, config)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// corpusEntry анализ одной работы в архиве прошлых запусков. Исходники не
+// хранятся отдельно: для сравнения хватает отпечатков и метаданных анализа.
+// Анализ сохраняется до вычитания базового кода - его вычитает загрузивший запуск.
+type corpusEntry struct {
+	Course      string
+	Assignment  string
+	Year        int
+	StoredAt    time.Time
+	Fingerprint FingerprintConfig // отпечатки сравнимы только при тех же k и окне
+	Project     Project
+}
+
+// corpusKey возвращает название курса и задания для путей архива
+func (c Config) corpusKey() (course, assignment string) {
+	course, assignment = c.Course, c.Assignment
+	if course == "" {
+		course = "default"
+	}
+	if assignment == "" {
+		assignment = "default"
+	}
+	return course, assignment
+}
+
+// corpusPath возвращает папку архива с работами курса и задания за год
+func (c Config) corpusPath(year int) string {
+	course, assignment := c.corpusKey()
+	return filepath.Join(c.CorpusDir, course, assignment, strconv.Itoa(year))
+}
+
+// saveToCorpus сохраняет анализ работ текущего запуска в архив под годом config.Year.
+// Работы того же года из прошлых запусков перезаписываются.
+func saveToCorpus(projects []Project) error {
+	dir := config.corpusPath(config.Year)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("создание папки архива: %w", err)
+	}
+
+	course, assignment := config.corpusKey()
+	for _, p := range projects {
+		entry := corpusEntry{
+			Course:      course,
+			Assignment:  assignment,
+			Year:        config.Year,
+			StoredAt:    time.Now(),
+			Fingerprint: config.Fingerprint,
+			Project:     p,
+		}
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("сериализация работы %s: %w", p.Name, err)
+		}
+		path := filepath.Join(dir, filepath.Base(p.Name)+".json")
+		if err := ioutil.WriteFile(path, data, 0o644); err != nil {
+			return fmt.Errorf("запись %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// loadCorpus загружает из архива работы того же курса и задания за все годы,
+// кроме текущего. Имя архивной работы получает префикс года, например "2023/alice".
+func loadCorpus() ([]Project, error) {
+	course, assignment := config.corpusKey()
+	root := filepath.Join(config.CorpusDir, course, assignment)
+
+	years, err := ioutil.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("чтение архива: %w", err)
+	}
+
+	var archive []Project
+	skipped := 0
+	for _, yearDir := range years {
+		year, err := strconv.Atoi(yearDir.Name())
+		if !yearDir.IsDir() || err != nil || year == config.Year {
+			continue
+		}
+
+		entries, err := ioutil.ReadDir(filepath.Join(root, yearDir.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("чтение архива за %d: %w", year, err)
+		}
+		for _, info := range entries {
+			if info.IsDir() || !strings.HasSuffix(info.Name(), ".json") {
+				continue
+			}
+			path := filepath.Join(root, yearDir.Name(), info.Name())
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("чтение %s: %w", path, err)
+			}
+			var entry corpusEntry
+			if err := json.Unmarshal(data, &entry); err != nil {
+				return nil, fmt.Errorf("разбор %s: %w", path, err)
+			}
+			if entry.Fingerprint != config.Fingerprint || !config.languageEnabled(entry.Project.Language) {
+				skipped++
+				continue
+			}
+
+			p := entry.Project
+			p.Name = fmt.Sprintf("%d/%s", year, p.Name)
+			p.ArchiveYear = year
+			archive = append(archive, p)
+		}
+	}
+
+	if skipped > 0 {
+		fmt.Printf("Пропущено архивных работ с другими параметрами отпечатков или языком: %d\n", skipped)
+	}
+	sort.Slice(archive, func(i, j int) bool { return archive[i].Name < archive[j].Name })
+	return archive, nil
+}
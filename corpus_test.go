@@ -0,0 +1,124 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCorpusRoundTrip(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+	config.CorpusDir = t.TempDir()
+	config.Course, config.Assignment = "algo", "lab1"
+
+	projects := engineProjects(2)
+	config.Year = 2023
+	if err := saveToCorpus(projects); err != nil {
+		t.Fatal(err)
+	}
+
+	// Работы текущего года не загружаются: они уже есть в запуске
+	archive, err := loadCorpus()
+	if err != nil || len(archive) != 0 {
+		t.Fatalf("архив текущего года: %d работ, ошибка %v", len(archive), err)
+	}
+
+	config.Year = 2024
+	archive, err = loadCorpus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archive) != len(projects) {
+		t.Fatalf("загружено %d работ, ожидалось %d", len(archive), len(projects))
+	}
+	for i, p := range archive {
+		if want := "2023/" + projects[i].Name; p.Name != want || p.ArchiveYear != 2023 {
+			t.Errorf("работа %q из %d, ожидалась %q из 2023", p.Name, p.ArchiveYear, want)
+		}
+		if !reflect.DeepEqual(p.Fingerprints, projects[i].Fingerprints) ||
+			!reflect.DeepEqual(p.Tokens, projects[i].Tokens) {
+			t.Errorf("%s: анализ изменился после сохранения", p.Name)
+		}
+	}
+
+	// Отпечатки с другим k несравнимы с текущими
+	config.Fingerprint.K++
+	if archive, err = loadCorpus(); err != nil || len(archive) != 0 {
+		t.Errorf("архив с другим k: %d работ, ошибка %v", len(archive), err)
+	}
+	config.Fingerprint.K--
+
+	config.Assignment = "lab2"
+	if archive, err = loadCorpus(); err != nil || len(archive) != 0 {
+		t.Errorf("архив другого задания: %d работ, ошибка %v", len(archive), err)
+	}
+}
+
+func TestCorpusRoundTripWithBase(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+
+	root := t.TempDir()
+	write := func(rel, content string) {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	const template = "# Прочитайте данные\ndef read_input():\n    n = int(input())\n    return [int(input()) for i in range(n)]\n"
+	write("base/main.py", template)
+	write("2023/alice/main.py", template+"\n# Сумма\ndef solve(xs):\n    total = 0\n    for x in xs:\n        if x > 0:\n            total += x\n    return total\n")
+	write("2023/bob/main.py", template+"\ndef answer(values):\n    return max(values)\n")
+
+	args := func(year string) []string {
+		return []string{
+			"-input", filepath.Join(root, year), "-output", filepath.Join(root, "reports"),
+			"-base", filepath.Join(root, "base"), "-corpus", filepath.Join(root, "corpus"),
+			"-course", "algo", "-year", year, "-format", "json",
+		}
+	}
+	if code := runScan(args("2023")); code == exitUsage || code == exitFailure {
+		t.Fatalf("scan 2023 завершился с кодом %d", code)
+	}
+
+	// Тот же анализ, что получил запуск 2023 года: шаблон вычтен один раз
+	base, err := loadBaseCode(filepath.Join(root, "base"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := loadProjects(filepath.Join(root, "2023"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range want {
+		subtractBaseCode(&want[i], base, baseCodeHashes(base))
+	}
+
+	// Запуск следующего года загружает архив и вычитает шаблон сам
+	config.Year = 2024
+	archive, err := loadCorpus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := prepareProjects(nil, archive); err != nil {
+		t.Fatal(err)
+	}
+	if len(archive) != len(want) {
+		t.Fatalf("в архиве %d работ, ожидалось %d", len(archive), len(want))
+	}
+	for i, p := range archive {
+		w := want[i]
+		if !reflect.DeepEqual(p.Identifiers, w.Identifiers) || !reflect.DeepEqual(p.Functions, w.Functions) ||
+			p.Comments != w.Comments || !reflect.DeepEqual(p.ControlFlow, w.ControlFlow) ||
+			!reflect.DeepEqual(p.Fingerprints, w.Fingerprints) || p.BaseRemoved != w.BaseRemoved {
+			t.Errorf("%s: анализ из архива отличается от вычтенного один раз\nархив: %+v %+v %q\nожидалось: %+v %+v %q",
+				p.Name, p.Identifiers, p.Functions.DeclareOrder, p.Comments, w.Identifiers, w.Functions.DeclareOrder, w.Comments)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Метка порядка байтов UTF-8: без нее Excel открывает CSV в однобайтовой
+// кодировке и портит кириллицу в заголовках
+const utf8BOM = "\xEF\xBB\xBF"
+
+// Excel с русской локалью делит столбцы точкой с запятой, а дробную часть
+// отделяет запятой; с разделителем "," вся строка попадает в одну ячейку
+const (
+	csvSeparator = ';'
+	csvDecimal   = ","
+)
+
+// csvNumber форматирует число для таблицы
+func csvNumber(value float64) string {
+	return strings.Replace(strconv.FormatFloat(value, 'f', 2, 64), ".", csvDecimal, 1)
+}
+
+// csvBool переводит флаг в ячейку таблицы
+func csvBool(value bool) string {
+	if value {
+		return "да"
+	}
+	return "нет"
+}
+
+// pairCSVRows строит таблицу с одной строкой на каждую сравненную пару.
+// Недоступная метрика дает пустую ячейку, чтобы не мешать сортировке по числам.
+func pairCSVRows(results []ComparisonResult, scoring ScoringConfig) [][]string {
+	header := []string{"Работа 1", "Работа 2", "Язык", "Разные языки", "Общая оценка", "Уровень", "Выше порога", "Год архива"}
+	for _, metric := range allMetrics {
+		header = append(header, metricLabels[metric])
+	}
+	if config.usesGST() {
+		header = append(header, "Покрытие GST")
+	}
+	header = append(header, "Доля 1 во 2", "Доля 2 в 1", "Совпавших отпечатков", "Совпавших фрагментов", "Похожих функций")
+
+	rows := [][]string{header}
+	for _, r := range results {
+		archive := ""
+		if r.ArchiveYear != 0 {
+			archive = strconv.Itoa(r.ArchiveYear)
+		}
+		row := []string{
+			r.Project1,
+			r.Project2,
+			r.Language,
+			csvBool(r.CrossLanguage),
+			csvNumber(r.OverallScore),
+			bandLabels[scoring.band(r.OverallScore)],
+			csvBool(scoring.flagged(r)),
+			archive,
+		}
+		for _, metric := range allMetrics {
+			if r.IsAvailable(metric) {
+				row = append(row, csvNumber(r.MetricValue(metric)))
+			} else {
+				row = append(row, "")
+			}
+		}
+		if config.usesGST() {
+			row = append(row, csvNumber(r.TileCoverage))
+		}
+		if r.CrossLanguage || r.IsAvailable(metricText) {
+			row = append(row, csvNumber(r.Containment1), csvNumber(r.Containment2))
+		} else {
+			row = append(row, "", "")
+		}
+		row = append(row, strconv.Itoa(len(r.Matches)), strconv.Itoa(len(r.Fragments)), strconv.Itoa(len(r.Clones)))
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// studentCSVRows строит таблицу с одной строкой на работу: максимальная схожесть,
+// самый похожий партнер и число пар не ниже порога
+func studentCSVRows(projects []ProjectSummary, results []ComparisonResult, scoring ScoringConfig) [][]string {
+	type studentStats struct {
+		best    float64
+		partner string
+		flagged int
+	}
+	stats := make(map[string]*studentStats)
+	for _, p := range projects {
+		stats[p.Name] = &studentStats{}
+	}
+
+	update := func(name, partner string, score float64, flagged bool) {
+		s, ok := stats[name]
+		if !ok {
+			// Архивные работы отдельных строк не получают
+			return
+		}
+		if s.partner == "" || score > s.best {
+			s.best, s.partner = score, partner
+		}
+		if flagged {
+			s.flagged++
+		}
+	}
+	for _, r := range results {
+		update(r.Project1, r.Project2, r.OverallScore, scoring.flagged(r))
+		update(r.Project2, r.Project1, r.OverallScore, scoring.flagged(r))
+	}
+
+	rows := [][]string{{"Работа", "Язык", "Файлов", "Максимальная схожесть", "Самый похожий партнер", "Пар выше порога"}}
+	for _, p := range projects {
+		s := stats[p.Name]
+		best := ""
+		if s.partner != "" {
+			best = csvNumber(s.best)
+		}
+		rows = append(rows, []string{p.Name, p.Language, strconv.Itoa(len(p.Files)), best, s.partner, strconv.Itoa(s.flagged)})
+	}
+	return rows
+}
+
+// writeCSV сохраняет таблицу в UTF-8 с меткой порядка байтов и разделителем ";"
+func writeCSV(path string, rows [][]string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("создание %s: %w", path, err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(utf8BOM); err != nil {
+		return fmt.Errorf("запись %s: %w", path, err)
+	}
+	writer := csv.NewWriter(file)
+	writer.Comma = csvSeparator
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("запись %s: %w", path, err)
+	}
+	return nil
+}
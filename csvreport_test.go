@@ -0,0 +1,44 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestCSVNumber(t *testing.T) {
+	tests := []struct {
+		value float64
+		want  string
+	}{
+		{0, "0,00"},
+		{12.5, "12,50"},
+		{100, "100,00"},
+		{33.333, "33,33"},
+	}
+	for _, tt := range tests {
+		if got := csvNumber(tt.value); got != tt.want {
+			t.Errorf("csvNumber(%v) = %q, ожидалось %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestWriteCSVUsesExcelRussianLayout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pairs.csv")
+	rows := [][]string{
+		{"Работа 1", "Работа 2", "Оценка"},
+		{"alice", "bob; carol", csvNumber(87.25)},
+	}
+	if err := writeCSV(path, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := utf8BOM + "Работа 1;Работа 2;Оценка\nalice;\"bob; carol\";87,25\n"
+	if string(data) != want {
+		t.Errorf("содержимое CSV:\n%q\nожидалось:\n%q", data, want)
+	}
+}
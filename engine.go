@@ -0,0 +1,145 @@
+package main
+
+import "sync"
+
+// ComparisonProgress сообщение о ходе сравнения всех пар
+type ComparisonProgress struct {
+	Done   int              // сколько пар уже сравнено
+	Total  int              // сколько пар нужно сравнить
+	Result ComparisonResult // результат только что сравненной пары
+}
+
+// comparisonJob пара проектов для сравнения и ее место в итоговом списке
+type comparisonJob struct {
+	index int
+	i, j  int
+}
+
+// ComparisonStats итоги отбора пар для сравнения
+type ComparisonStats struct {
+	TotalPairs int // пар проектов, подлежащих сравнению
+	Compared   int // пар, прошедших предварительный отбор
+	Pruned     int // пар с архивными работами, отсеянных по индексу отпечатков
+	Cached     int // пар, результат которых взят из кэша
+	CrossPairs int // пар на разных языках (отбираются по индексу k-грамм IR)
+}
+
+// comparisonPairs перечисляет пары проектов на одном языке, а при crossLanguage -
+// и на разных языках, в порядке (i, j), i < j. Если minShared больше нуля, пары с
+// архивной работой и меньшим числом общих отпечатков отсеиваются по инвертированному
+// индексу без полного сравнения; отпечатки общих заготовок при этом не считаются.
+// Пары на разных языках отсеиваются так же, но по общим k-граммам IR: отпечатки
+// токенов у них не совпадают. Пары работ текущего запуска сравниваются всегда:
+// по ним строится полная матрица когорты.
+func comparisonPairs(projects []Project, minShared int, crossLanguage bool) ([]comparisonJob, ComparisonStats) {
+	hasArchive := false
+	for _, p := range projects {
+		hasArchive = hasArchive || p.ArchiveYear != 0
+	}
+
+	var shared, sharedIR map[[2]int]int
+	if minShared > 0 && hasArchive {
+		shared = buildFingerprintIndex(projects).sharedFingerprints(len(projects))
+		if crossLanguage {
+			sharedIR = buildIRIndex(projects).sharedFingerprints(len(projects))
+		}
+	}
+
+	var jobs []comparisonJob
+	var stats ComparisonStats
+	for i := 0; i < len(projects); i++ {
+		for j := i + 1; j < len(projects); j++ {
+			// Архивные работы сравниваются только с работами текущего запуска
+			if projects[i].ArchiveYear != 0 && projects[j].ArchiveYear != 0 {
+				continue
+			}
+			cross := projects[i].Language != projects[j].Language
+			if cross && !crossLanguage {
+				continue
+			}
+			stats.TotalPairs++
+			common := shared[[2]int{i, j}]
+			if cross {
+				stats.CrossPairs++
+				common = sharedIR[[2]int{i, j}]
+			}
+			archivePair := projects[i].ArchiveYear != 0 || projects[j].ArchiveYear != 0
+			if archivePair && minShared > 0 && common < minShared {
+				stats.Pruned++
+				continue
+			}
+			jobs = append(jobs, comparisonJob{index: len(jobs), i: i, j: j})
+		}
+	}
+	stats.Compared = len(jobs)
+	return jobs, stats
+}
+
+// compareAllProjects сравнивает в workers потоков пары проектов на одном языке и,
+// если включен config.CrossLanguage, на разных языках; пары с архивными работами -
+// только прошедшие отбор по config.MinShared. Порядок результатов не зависит
+// от числа потоков: пары идут в порядке проектов. Если progress не nil, после
+// каждой пары в него отправляется сообщение, а по окончании канал закрывается.
+func compareAllProjects(projects []Project, workers int, progress chan<- ComparisonProgress) ([]ComparisonResult, ComparisonStats) {
+	if progress != nil {
+		defer close(progress)
+	}
+
+	pairs, stats := comparisonPairs(projects, config.MinShared, config.CrossLanguage)
+	results := make([]ComparisonResult, len(pairs))
+	if workers < 1 {
+		workers = 1
+	}
+
+	// Отпечатки работ для кэша пар считаются один раз, а не для каждой пары
+	digests := make([]string, len(projects))
+	if config.CacheDir != "" {
+		for i, p := range projects {
+			digests[i] = projectDigest(p)
+		}
+	}
+
+	type finishedJob struct {
+		comparisonJob
+		cached bool
+	}
+	jobs := make(chan comparisonJob)
+	finished := make(chan finishedJob)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				// Каждый поток пишет только в свою ячейку, синхронизация не нужна
+				var cached bool
+				results[job.index], cached = compareProjectsCached(projects[job.i], projects[job.j], digests[job.i], digests[job.j])
+				finished <- finishedJob{job, cached}
+			}
+		}()
+	}
+
+	go func() {
+		for _, job := range pairs {
+			jobs <- job
+		}
+		close(jobs)
+		wg.Wait()
+		close(finished)
+	}()
+
+	// Прогресс отправляется из одного места, поэтому Done растет монотонно
+	done := 0
+	for job := range finished {
+		done++
+		if job.cached {
+			stats.Cached++
+		}
+		if progress != nil {
+			progress <- ComparisonProgress{Done: done, Total: len(pairs), Result: results[job.index]}
+		}
+	}
+
+	return results, stats
+}
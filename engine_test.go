@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// engineProjects работы с одной функцией, отличающиеся размером тела
+func engineProjects(n int) []Project {
+	var projects []Project
+	for i := 0; i < n; i++ {
+		src := "def solve(xs):\n    s = 0\n    for x in xs:\n"
+		for k := 0; k <= i%4; k++ {
+			src += fmt.Sprintf("        s += x * %d\n", k)
+		}
+		src += "    return s\n"
+		name := fmt.Sprint("student", i)
+		dir := filepath.Join("work", name)
+		file := analyzeFile(filepath.Join(dir, "main.py"), src, "python")
+		projects = append(projects, buildProject(name, dir, []SourceFile{file}))
+	}
+	return projects
+}
+
+// languageProject пустая работа на языке language
+func languageProject(name, language string) Project {
+	p := Project{Name: name}
+	p.Language = language
+	return p
+}
+
+func TestCompareAllProjectsOrder(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+	config.MinShared = 0
+	config.CacheDir = ""
+
+	projects := engineProjects(7)
+	sequential, stats := compareAllProjects(projects, 1, nil)
+	if stats.TotalPairs != 21 || stats.Compared != 21 || len(sequential) != 21 {
+		t.Fatalf("пар %d, сравнено %d, результатов %d; ожидалось 21", stats.TotalPairs, stats.Compared, len(sequential))
+	}
+
+	// Пары идут в порядке (i, j), i < j
+	k := 0
+	for i := range projects {
+		for j := i + 1; j < len(projects); j++ {
+			if r := sequential[k]; r.Project1 != projects[i].Name || r.Project2 != projects[j].Name {
+				t.Errorf("результат %d: %s/%s, ожидалось %s/%s", k, r.Project1, r.Project2, projects[i].Name, projects[j].Name)
+			}
+			k++
+		}
+	}
+
+	for _, workers := range []int{0, 3, 16} {
+		progress := make(chan ComparisonProgress)
+		var done []int
+		finished := make(chan bool)
+		go func() {
+			for p := range progress {
+				if p.Total != 21 {
+					t.Errorf("Total = %d, ожидалось 21", p.Total)
+				}
+				done = append(done, p.Done)
+			}
+			finished <- true
+		}()
+		parallel, _ := compareAllProjects(projects, workers, progress)
+		<-finished
+
+		if !reflect.DeepEqual(parallel, sequential) {
+			t.Errorf("%d потоков: результаты отличаются от последовательного сравнения", workers)
+		}
+		for i, d := range done {
+			if d != i+1 {
+				t.Errorf("%d потоков: прогресс %v не растет по одному", workers, done)
+				break
+			}
+		}
+		if len(done) != 21 {
+			t.Errorf("%d потоков: сообщений о прогрессе %d, ожидалось 21", workers, len(done))
+		}
+	}
+}
+
+func TestComparisonPairsSkipsOtherLanguages(t *testing.T) {
+	projects := []Project{languageProject("a", "python"), languageProject("b", "java"), languageProject("c", "python")}
+	jobs, _ := comparisonPairs(projects, 0, false)
+	if len(jobs) != 1 || jobs[0].i != 0 || jobs[0].j != 2 {
+		t.Errorf("отобраны %v, ожидалась пара (0, 2)", jobs)
+	}
+}
+
+func TestComparisonPairsSkipsArchivePairs(t *testing.T) {
+	projects := []Project{
+		languageProject("a", "python"), languageProject("b", "python"),
+		languageProject("old1", "python"), languageProject("old2", "python"),
+	}
+	projects[2].ArchiveYear, projects[3].ArchiveYear = 2023, 2024
+	jobs, stats := comparisonPairs(projects, 0, false)
+	if stats.TotalPairs != 5 || len(jobs) != 5 {
+		t.Errorf("пар %d, отобрано %d; ожидалось 5 без пары двух архивных работ", stats.TotalPairs, len(jobs))
+	}
+	for _, job := range jobs {
+		if job.i == 2 && job.j == 3 {
+			t.Error("архивные работы сравнены друг с другом")
+		}
+	}
+}
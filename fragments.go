@@ -0,0 +1,132 @@
+package main
+
+import "sort"
+
+// MatchedFragment совпавший участок кода двух работ: файлы (индексы в Files1/Files2)
+// и позиции первого и последнего токена участка
+type MatchedFragment struct {
+	File1, File2 int
+	Start1, End1 SourcePos
+	Start2, End2 SourcePos
+	Tokens       int // длина совпадения в токенах
+}
+
+// tokenSpan совпавший отрезок потоков токенов двух работ
+type tokenSpan struct {
+	start1, end1 int
+	start2, end2 int
+}
+
+// matchedSpans собирает отрезки из совпавших отпечатков и отрезков GST и сливает
+// пересекающиеся или соседние на обеих сторонах
+func matchedSpans(matches []FingerprintMatch, tiles []Tile, k int) []tokenSpan {
+	var spans []tokenSpan
+	for _, m := range matches {
+		spans = append(spans, tokenSpan{m.Pos1, m.Pos1 + k, m.Pos2, m.Pos2 + k})
+	}
+	for _, t := range tiles {
+		spans = append(spans, tokenSpan{t.Start1, t.Start1 + t.Length, t.Start2, t.Start2 + t.Length})
+	}
+	if len(spans) == 0 {
+		return nil
+	}
+
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].start1 != spans[j].start1 {
+			return spans[i].start1 < spans[j].start1
+		}
+		return spans[i].start2 < spans[j].start2
+	})
+
+	merged := []tokenSpan{spans[0]}
+	for _, s := range spans[1:] {
+		last := &merged[len(merged)-1]
+		if s.start1 <= last.end1 && s.start2 >= last.start2 && s.start2 <= last.end2 {
+			last.end1 = max(last.end1, s.end1)
+			last.end2 = max(last.end2, s.end2)
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+// containment возвращает доли токенов каждой работы, покрытых совпавшими
+// отпечатками и отрезками GST: сколько процентов первой работы найдено во второй
+// и сколько процентов второй - в первой. В отличие от симметричных оценок, не
+// занижает схожесть, когда небольшая работа целиком вошла в большую.
+func containment(matches []FingerprintMatch, tiles []Tile, k, len1, len2 int) (float64, float64) {
+	covered1 := make([]bool, len1)
+	covered2 := make([]bool, len2)
+	mark := func(covered []bool, start, length int) {
+		for i := start; i < start+length && i < len(covered); i++ {
+			covered[i] = true
+		}
+	}
+	for _, m := range matches {
+		mark(covered1, m.Pos1, k)
+		mark(covered2, m.Pos2, k)
+	}
+	for _, t := range tiles {
+		mark(covered1, t.Start1, t.Length)
+		mark(covered2, t.Start2, t.Length)
+	}
+	return coveredShare(covered1), coveredShare(covered2)
+}
+
+// coveredShare возвращает процент отмеченных токенов
+func coveredShare(covered []bool) float64 {
+	if len(covered) == 0 {
+		return 0
+	}
+	count := 0
+	for _, c := range covered {
+		if c {
+			count++
+		}
+	}
+	return float64(count) / float64(len(covered)) * 100
+}
+
+// sourceRange переводит отрезок сводного потока токенов работы в файл и позиции.
+// Отрезок, заходящий в следующий файл, обрезается по концу первого файла.
+func (p Project) sourceRange(start, end int) (file int, first, last SourcePos, ok bool) {
+	if start >= end || end > len(p.Tokens.TokenPositions) {
+		return 0, first, last, false
+	}
+	file, _ = p.fileAt(start)
+	endFile, _ := p.fileAt(end - 1)
+	if endFile != file {
+		end = p.Files[file].TokenOffset + len(p.Files[file].Tokens.TokenPatterns)
+	}
+	return file, p.Tokens.TokenPositions[start], p.Tokens.TokenPositions[end-1], true
+}
+
+// matchedFragments переводит совпадения пары в участки исходников для показа
+func matchedFragments(p1, p2 Project, matches []FingerprintMatch, tiles []Tile) []MatchedFragment {
+	var fragments []MatchedFragment
+	for _, s := range matchedSpans(matches, tiles, config.Fingerprint.K) {
+		file1, start1, end1, ok1 := p1.sourceRange(s.start1, s.end1)
+		file2, start2, end2, ok2 := p2.sourceRange(s.start2, s.end2)
+		if !ok1 || !ok2 {
+			// Анализ без позиций токенов (например, из старого архива)
+			continue
+		}
+		fragments = append(fragments, MatchedFragment{
+			File1:  file1,
+			File2:  file2,
+			Start1: start1,
+			End1:   end1,
+			Start2: start2,
+			End2:   end2,
+			Tokens: min(s.end1-s.start1, s.end2-s.start2),
+		})
+	}
+	return fragments
+}
+
+// fragmentLocations возвращает начала фрагмента в файлах обеих работ
+func (r ComparisonResult) fragmentLocations(f MatchedFragment) (SourceLocation, SourceLocation) {
+	return SourceLocation{File: fileName(r.Files1, f.File1), Line: f.Start1.Line, Column: f.Start1.Column},
+		SourceLocation{File: fileName(r.Files2, f.File2), Line: f.Start2.Line, Column: f.Start2.Column}
+}
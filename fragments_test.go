@@ -0,0 +1,98 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestContainment(t *testing.T) {
+	tests := []struct {
+		name       string
+		matches    []FingerprintMatch
+		tiles      []Tile
+		k          int
+		len1, len2 int
+		want1      float64
+		want2      float64
+	}{
+		{"без совпадений", nil, nil, 5, 10, 20, 0, 0},
+		{"малая работа целиком в большой", nil, []Tile{{0, 30, 10}}, 5, 10, 40, 100, 25},
+		{"пересекающиеся отпечатки не считаются дважды", []FingerprintMatch{{1, 0, 0}, {2, 2, 2}}, nil, 4, 10, 10, 60, 60},
+		{"отпечаток у конца потока", []FingerprintMatch{{1, 8, 0}}, nil, 5, 10, 5, 20, 100},
+		{"отпечатки и отрезки вместе", []FingerprintMatch{{1, 0, 0}}, []Tile{{5, 5, 5}}, 5, 10, 20, 100, 50},
+		{"пустые работы", nil, nil, 5, 0, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		got1, got2 := containment(tt.matches, tt.tiles, tt.k, tt.len1, tt.len2)
+		if got1 != tt.want1 || got2 != tt.want2 {
+			t.Errorf("%s: %.1f/%.1f, ожидалось %.1f/%.1f", tt.name, got1, got2, tt.want1, tt.want2)
+		}
+	}
+}
+
+func TestMatchedSpans(t *testing.T) {
+	matches := []FingerprintMatch{{1, 0, 10}, {2, 3, 13}, {3, 20, 0}}
+	tiles := []Tile{{Start1: 6, Start2: 16, Length: 4}}
+	want := []tokenSpan{{0, 10, 10, 20}, {20, 25, 0, 5}}
+	if got := matchedSpans(matches, tiles, 5); !reflect.DeepEqual(got, want) {
+		t.Errorf("отрезки %v, ожидалось %v", got, want)
+	}
+	if matchedSpans(nil, nil, 5) != nil {
+		t.Error("без совпадений отрезков быть не должно")
+	}
+}
+
+// Небольшая работа, целиком вошедшая в большую: доли вхождения разные, а общая
+// оценка не зависит от порядка работ в паре
+func TestCompareProjectsDirectionalContainment(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+
+	small := "def area(w, h):\n    if w < 0 or h < 0:\n        raise ValueError('size')\n    return w * h\n"
+	large := small + `
+
+def perimeter(w, h):
+    total = 0
+    for side in (w, h, w, h):
+        total += side
+    return total
+
+
+class Report:
+    def __init__(self, items):
+        self.items = sorted(items, key=lambda x: x[1])
+
+    def render(self):
+        lines = []
+        for name, value in self.items:
+            lines.append(name + ': ' + str(value))
+        return '\n'.join(lines)
+`
+	project := func(name, src string) Project {
+		dir := filepath.Join("work", name)
+		return buildProject(name, dir, []SourceFile{analyzeFile(filepath.Join(dir, "main.py"), src, "python")})
+	}
+	p1, p2 := project("small", small), project("large", large)
+
+	for _, algorithm := range []string{algorithmWinnowing, algorithmBoth} {
+		config.Algorithm = algorithm
+		forward := compareProjects(p1, p2)
+		backward := compareProjects(p2, p1)
+		if forward.Containment1 < 3*forward.Containment2 {
+			t.Errorf("%s: доли вхождения %.1f/%.1f: малая работа должна входить в большую, а не наоборот",
+				algorithm, forward.Containment1, forward.Containment2)
+		}
+		if forward.Containment1 != backward.Containment2 || forward.Containment2 != backward.Containment1 {
+			t.Errorf("%s: доли вхождения не меняются местами: %.1f/%.1f и %.1f/%.1f",
+				algorithm, forward.Containment1, forward.Containment2, backward.Containment1, backward.Containment2)
+		}
+		if forward.OverallScore != backward.OverallScore {
+			t.Errorf("%s: общая оценка зависит от порядка: %.4f и %.4f", algorithm, forward.OverallScore, backward.OverallScore)
+		}
+		// Отрезки GST покрывают скопированную работу целиком
+		if algorithm == algorithmBoth && forward.Containment1 != 100 {
+			t.Errorf("%s: найдено %.1f%% малой работы, ожидалось 100%%", algorithm, forward.Containment1)
+		}
+	}
+}
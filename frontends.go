@@ -0,0 +1,908 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// sourceFrontend описывает синтаксис объявлений языка для анализа по лексемам.
+// Go разбирается настоящим AST (goast.go), остальные языки - по лексемам.
+type sourceFrontend struct {
+	functionKeywords  map[string]bool   // def, fn, function
+	classKeywords     map[string]bool   // class, struct, enum, module
+	interfaceKeywords map[string]bool   // interface, trait
+	variableKeywords  map[string]bool   // let, var
+	constantKeywords  map[string]bool   // const, final, static, define
+	importKeywords    map[string]bool   // import, using, use, require, include
+	control           map[string]string // ключевое слово -> if, for, while или switch
+	endBlocks         bool              // блоки закрываются словом end (Ruby)
+	indentBlocks      bool              // блок открывает ":" в конце строки, а задает отступ (Python)
+	assignDeclares    bool              // переменная объявляется присваиванием "имя = ..." (Python, Ruby)
+	moduleImports     bool              // импорты вида "import a.b as c" и "from a import b" (Python)
+	preprocessor      bool              // импорты - директивы #include
+	typedFunctions    bool              // функции и методы без ключевого слова: "тип имя(...) {"
+	typedVariables    bool              // переменные объявляются как "тип имя = ..."
+	typedParams       bool              // у параметров указываются типы
+	returnArrow       string            // разделитель типа результата после параметров: "->" или ":"
+}
+
+// frontendAnalysis результат анализа файла по лексемам
+type frontendAnalysis struct {
+	Identifiers Identifiers
+	Functions   FunctionAnalysis
+	Imports     ImportAnalysis
+	ControlFlow ControlFlow
+	Bodies      []FunctionFingerprint // отпечатки тел функций по лексемам (без имени файла)
+}
+
+// Управляющие конструкции языков с синтаксисом C
+var cStyleControl = map[string]string{"if": "if", "for": "for", "while": "while", "switch": "switch"}
+
+// Ключевые слова, которые могут быть типом в объявлении переменной
+var typeKeywords = keywordSet(`int long short char float double bool boolean byte void auto var string
+	unsigned signed decimal object uint ulong ushort sbyte`)
+
+// Модификаторы, которые не входят в тип результата функции
+var declarationModifiers = keywordSet(`public private protected internal static final abstract virtual override
+	inline extern async sealed synchronized native explicit constexpr unsafe`)
+
+// Фронтенды для языков без разбора AST
+var sourceFrontends = map[string]sourceFrontend{
+	"python": {
+		functionKeywords: keywordSet("def"),
+		classKeywords:    keywordSet("class"),
+		importKeywords:   keywordSet("import from"),
+		control:          map[string]string{"if": "if", "elif": "if", "for": "for", "while": "while"},
+		indentBlocks:     true,
+		assignDeclares:   true,
+		moduleImports:    true,
+		returnArrow:      "->",
+	},
+	"java": {
+		classKeywords:     keywordSet("class enum"),
+		interfaceKeywords: keywordSet("interface"),
+		variableKeywords:  keywordSet("var"),
+		constantKeywords:  keywordSet("final"),
+		importKeywords:    keywordSet("import"),
+		control:           cStyleControl,
+		typedFunctions:    true,
+		typedVariables:    true,
+		typedParams:       true,
+	},
+	"javascript": {
+		functionKeywords: keywordSet("function"),
+		classKeywords:    keywordSet("class"),
+		variableKeywords: keywordSet("let var"),
+		constantKeywords: keywordSet("const"),
+		importKeywords:   keywordSet("import require"),
+		control:          cStyleControl,
+		typedFunctions:   true, // методы классов объявляются без function
+	},
+	"c": {
+		classKeywords:    keywordSet("struct enum union"),
+		constantKeywords: keywordSet("const define"),
+		importKeywords:   keywordSet("include"),
+		control:          cStyleControl,
+		preprocessor:     true,
+		typedFunctions:   true,
+		typedVariables:   true,
+		typedParams:      true,
+	},
+	"c++": {
+		classKeywords:    keywordSet("class struct enum union"),
+		constantKeywords: keywordSet("const constexpr define"),
+		importKeywords:   keywordSet("include"),
+		control:          cStyleControl,
+		preprocessor:     true,
+		typedFunctions:   true,
+		typedVariables:   true,
+		typedParams:      true,
+	},
+	"c#": {
+		classKeywords:     keywordSet("class struct enum"),
+		interfaceKeywords: keywordSet("interface"),
+		constantKeywords:  keywordSet("const readonly"),
+		importKeywords:    keywordSet("using"),
+		control:           map[string]string{"if": "if", "for": "for", "foreach": "for", "while": "while", "switch": "switch"},
+		typedFunctions:    true,
+		typedVariables:    true,
+		typedParams:       true,
+	},
+	"php": {
+		functionKeywords:  keywordSet("function fn"),
+		classKeywords:     keywordSet("class enum"),
+		interfaceKeywords: keywordSet("interface trait"),
+		constantKeywords:  keywordSet("const"),
+		importKeywords:    keywordSet("use require require_once include include_once"),
+		control: map[string]string{"if": "if", "elseif": "if", "for": "for", "foreach": "for", "while": "while",
+			"switch": "switch", "match": "switch"},
+		typedParams: true,
+		returnArrow: ":",
+	},
+	"ruby": {
+		functionKeywords: keywordSet("def"),
+		classKeywords:    keywordSet("class module"),
+		importKeywords:   keywordSet("require require_relative"),
+		control: map[string]string{"if": "if", "unless": "if", "elsif": "if", "for": "for", "while": "while",
+			"until": "while", "case": "switch"},
+		endBlocks:      true,
+		assignDeclares: true,
+	},
+	"rust": {
+		functionKeywords:  keywordSet("fn"),
+		classKeywords:     keywordSet("struct enum"),
+		interfaceKeywords: keywordSet("trait"),
+		variableKeywords:  keywordSet("let"),
+		constantKeywords:  keywordSet("const static"),
+		importKeywords:    keywordSet("use"),
+		control:           map[string]string{"if": "if", "for": "for", "while": "while", "loop": "while", "match": "switch"},
+		typedParams:       true,
+		returnArrow:       "->",
+	},
+}
+
+// Блоки, которые Ruby закрывает словом end
+var rubyBlockKeywords = keywordSet("def class module do begin case")
+
+// Управляющие слова Ruby, открывающие блок только в начале строки (а не как модификатор)
+var rubyStatementKeywords = keywordSet("if unless while until for")
+
+// openBlock открытый блок кода
+type openBlock struct {
+	control   bool      // блок управляющей конструкции
+	function  string    // имя функции, тело которой открывает блок
+	start     SourcePos // начало объявления функции
+	bodyStart int       // первая лексема тела (в Ruby - объявление функции)
+	indent    int       // столбец начала строки заголовка (Python)
+}
+
+// frontendState состояние прохода по лексемам файла
+type frontendState struct {
+	spec   sourceFrontend
+	tokens []Token
+	result frontendAnalysis
+
+	blocks          []openBlock
+	parenDepth      int
+	pendingControl  bool   // следующая "{" открывает тело управляющей конструкции
+	pendingFunction string // следующая "{" открывает тело этой функции
+	pendingPos      SourcePos
+	lineStart       int // первая лексема текущей строки (Python)
+	pattern         []string
+	classes         map[string]bool
+	imported        map[string]string // локальное имя -> импорт (Python: import a.b as c)
+}
+
+// analyzeSourceTokens анализирует файл по лексемам, если для языка есть фронтенд
+func analyzeSourceTokens(tokens []Token, language string) (frontendAnalysis, bool) {
+	spec, ok := sourceFrontends[language]
+	if !ok {
+		return frontendAnalysis{}, false
+	}
+
+	s := &frontendState{
+		spec:   spec,
+		tokens: tokens,
+		result: frontendAnalysis{
+			Functions: FunctionAnalysis{
+				ParamCount:    make(map[string]int),
+				ReturnTypes:   make(map[string]string),
+				FunctionSizes: make(map[string]int),
+				ParamTypes:    make(map[string]string),
+			},
+			Imports: ImportAnalysis{UsagePatterns: make(map[string]string)},
+		},
+		classes:  make(map[string]bool),
+		imported: make(map[string]string),
+	}
+	for i := range tokens {
+		s.visit(i)
+	}
+	// Блоки, не закрытые до конца файла (в Python - всегда), заканчиваются на последней строке
+	for len(s.blocks) > 0 {
+		s.close(len(tokens) - 1)
+	}
+
+	s.result.ControlFlow.ControlPattern = strings.Join(s.pattern, "->")
+	s.result.Imports.ImportOrder = strings.Join(s.result.Imports.ImportList, ",")
+	s.collectImportUsage()
+	return s.result, true
+}
+
+// visit разбирает одну лексему
+func (s *frontendState) visit(i int) {
+	token := s.tokens[i]
+	spec := s.spec
+
+	switch {
+	case spec.indentBlocks:
+		s.indentation(i)
+	case token.Text == "{" && !spec.endBlocks:
+		s.open(openBlock{control: s.pendingControl, function: s.pendingFunction, start: s.pendingPos, bodyStart: i})
+		s.pendingControl, s.pendingFunction = false, ""
+	case token.Text == "}" && !spec.endBlocks:
+		s.close(i)
+	case token.Text == "(":
+		s.parenDepth++
+	case token.Text == ")":
+		s.parenDepth = max(0, s.parenDepth-1)
+	case token.Text == ";" && s.parenDepth == 0:
+		// Тело без фигурных скобок или объявление без тела
+		s.pendingControl, s.pendingFunction = false, ""
+	case token.Text == "end" && spec.endBlocks:
+		s.close(i)
+	}
+
+	if token.Kind == TokenKeyword {
+		s.visitKeyword(i)
+	}
+	if token.Kind == TokenIdentifier {
+		s.visitIdentifier(i)
+	}
+}
+
+// indentation ведет блоки Python: блок открывает ":" в конце строки заголовка,
+// а закрывает первая строка с отступом не больше, чем у заголовка. Строки внутри
+// скобок - продолжение предыдущей строки.
+func (s *frontendState) indentation(i int) {
+	token := s.tokens[i]
+	if s.parenDepth == 0 && (i == 0 || s.tokens[i-1].Pos.Line != token.Pos.Line) {
+		for len(s.blocks) > 0 && s.blocks[len(s.blocks)-1].indent >= token.Pos.Column {
+			s.close(i - 1)
+		}
+		// Заголовок без ":" в конце строки (a if b else c) блока не открыл
+		s.pendingControl, s.pendingFunction = false, ""
+		s.lineStart = i
+	}
+
+	switch token.Text {
+	case "(", "[", "{":
+		s.parenDepth++
+	case ")", "]", "}":
+		s.parenDepth = max(0, s.parenDepth-1)
+	case ":":
+		if s.parenDepth > 0 {
+			// Срез, словарь или аннотация параметра
+			return
+		}
+		// "if x: return y" в одну строку блока не открывает
+		if next := s.at(i + 1); next.Kind < 0 || next.Pos.Line != token.Pos.Line {
+			s.open(openBlock{
+				control:   s.pendingControl,
+				function:  s.pendingFunction,
+				start:     s.pendingPos,
+				bodyStart: i + 1,
+				indent:    s.tokens[s.lineStart].Pos.Column,
+			})
+		}
+		s.pendingControl, s.pendingFunction = false, ""
+	}
+}
+
+// visitKeyword обрабатывает управляющие конструкции и объявления
+func (s *frontendState) visitKeyword(i int) {
+	word := s.tokens[i].Text
+	spec := s.spec
+
+	if kind, ok := spec.control[word]; ok {
+		s.countControl(kind)
+		if spec.endBlocks {
+			if rubyStatementKeywords[word] && s.startsStatement(i) {
+				s.open(openBlock{control: true})
+			} else if word == "case" {
+				s.open(openBlock{control: true})
+			}
+		} else {
+			s.pendingControl = true
+		}
+		return
+	}
+	if spec.endBlocks && rubyBlockKeywords[word] && word != "def" {
+		// do после while/until/for на той же строке не открывает отдельный блок
+		if word == "do" && len(s.blocks) > 0 && s.blocks[len(s.blocks)-1].control && s.previousOnLine(i, rubyStatementKeywords) {
+			return
+		}
+		s.open(openBlock{})
+	}
+
+	switch {
+	case spec.functionKeywords[word]:
+		s.keywordFunction(i)
+	case spec.classKeywords[word]:
+		if name := s.declaredName(i); name != "" && !s.classes[name] {
+			s.classes[name] = true
+			s.result.Identifiers.Classes = append(s.result.Identifiers.Classes, name)
+		}
+	case spec.interfaceKeywords[word]:
+		if name := s.declaredName(i); name != "" {
+			s.result.Identifiers.Interfaces = append(s.result.Identifiers.Interfaces, name)
+		}
+	case spec.constantKeywords[word]:
+		s.declaration(i, &s.result.Identifiers.Constants)
+	case spec.variableKeywords[word]:
+		s.declaration(i, &s.result.Identifiers.Variables)
+	case spec.importKeywords[word]:
+		s.importStatement(i)
+	}
+}
+
+// visitIdentifier обрабатывает функции без ключевого слова, импорты-вызовы
+// (require в JavaScript) и переменные без ключевого слова
+func (s *frontendState) visitIdentifier(i int) {
+	spec := s.spec
+	token := s.tokens[i]
+
+	if spec.importKeywords[token.Text] {
+		s.importStatement(i)
+		return
+	}
+	// Имя после function уже разобрано в keywordFunction
+	if prev := s.at(i - 1).Text; spec.functionKeywords[prev] || (prev == "&" && spec.functionKeywords[s.at(i-2).Text]) {
+		return
+	}
+	if spec.typedFunctions && s.typedFunction(i) {
+		return
+	}
+
+	next := s.at(i + 1)
+	switch {
+	case spec.typedVariables && s.typedVariable(i):
+		s.result.Identifiers.Variables = append(s.result.Identifiers.Variables, token.Text)
+	case strings.HasPrefix(token.Text, "$") && next.Text == "=" && token.Text != "$this":
+		// PHP: переменная появляется при первом присваивании
+		s.result.Identifiers.Variables = append(s.result.Identifiers.Variables, token.Text)
+	case spec.assignDeclares && next.Text == "=" && s.startsStatement(i):
+		// Имя с заглавной буквы - константа (в Ruby по правилам языка, в Python по соглашению)
+		if first := strings.TrimLeft(token.Text, "@$"); first != "" && first[0] >= 'A' && first[0] <= 'Z' {
+			s.result.Identifiers.Constants = append(s.result.Identifiers.Constants, token.Text)
+		} else {
+			s.result.Identifiers.Variables = append(s.result.Identifiers.Variables, token.Text)
+		}
+	}
+}
+
+// at возвращает лексему по индексу или пустую лексему за границами файла
+func (s *frontendState) at(i int) Token {
+	if i < 0 || i >= len(s.tokens) {
+		return Token{Kind: -1}
+	}
+	return s.tokens[i]
+}
+
+// startsStatement проверяет, что лексема первая в строке или после ";"
+func (s *frontendState) startsStatement(i int) bool {
+	prev := s.at(i - 1)
+	return i == 0 || prev.Pos.Line != s.tokens[i].Pos.Line || prev.Text == ";"
+}
+
+// previousOnLine проверяет, есть ли перед лексемой на той же строке одно из слов
+func (s *frontendState) previousOnLine(i int, words map[string]bool) bool {
+	line := s.tokens[i].Pos.Line
+	for j := i - 1; j >= 0 && s.tokens[j].Pos.Line == line; j-- {
+		if words[s.tokens[j].Text] {
+			return true
+		}
+	}
+	return false
+}
+
+// open открывает блок и обновляет глубину вложенности управляющих конструкций
+func (s *frontendState) open(block openBlock) {
+	s.blocks = append(s.blocks, block)
+	depth := 0
+	for _, b := range s.blocks {
+		if b.control {
+			depth++
+		}
+	}
+	s.result.ControlFlow.MaxNesting = max(s.result.ControlFlow.MaxNesting, depth)
+}
+
+// close закрывает блок последней лексемой last; закрытие тела функции дает ее
+// размер в строках и отпечаток тела для поиска клонов
+func (s *frontendState) close(last int) {
+	if len(s.blocks) == 0 {
+		return
+	}
+	block := s.blocks[len(s.blocks)-1]
+	s.blocks = s.blocks[:len(s.blocks)-1]
+	if block.function == "" {
+		return
+	}
+	s.result.Functions.FunctionSizes[block.function] = s.tokens[last].Pos.Line - block.start.Line + 1
+	if block.bodyStart <= last {
+		if body, ok := tokenFunctionFingerprint(block.function, s.tokens[block.bodyStart:last+1], block.start); ok {
+			s.result.Bodies = append(s.result.Bodies, body)
+		}
+	}
+}
+
+// countControl учитывает управляющую конструкцию
+func (s *frontendState) countControl(kind string) {
+	cf := &s.result.ControlFlow
+	switch kind {
+	case "if":
+		cf.IfCount++
+	case "for":
+		cf.ForCount++
+	case "while":
+		cf.WhileCount++
+	case "switch":
+		cf.SwitchCount++
+	}
+	s.pattern = append(s.pattern, kind)
+}
+
+// declaredName возвращает имя после ключевого слова объявления. В C++ class
+// внутри параметров шаблона (<class T>) объявлением не считается.
+func (s *frontendState) declaredName(i int) string {
+	if prev := s.at(i - 1).Text; prev == "<" || prev == "," {
+		return ""
+	}
+	next := s.at(i + 1)
+	if next.Kind != TokenIdentifier {
+		return ""
+	}
+	return next.Text
+}
+
+// declaration добавляет имена, объявленные после let, var, const и т.п.: берется
+// последнее имя до "=", ":" или ";" (const int X = 1, let mut x: i32, const x = 1)
+func (s *frontendState) declaration(i int, target *[]string) {
+	if s.tokens[i].Text == "define" {
+		if next := s.at(i + 1); next.Kind == TokenIdentifier {
+			*target = append(*target, next.Text)
+		}
+		return
+	}
+
+	// const у параметра (f(const char *s)) - не объявление константы
+	if prev := s.at(i - 1).Text; prev == "(" || prev == "," {
+		return
+	}
+
+	name := ""
+	for j := i + 1; j < len(s.tokens); j++ {
+		t := s.tokens[j]
+		if containsString([]string{"=", ":", ";", ",", "(", ")", "{"}, t.Text) || t.Pos.Line != s.tokens[i].Pos.Line {
+			break
+		}
+		if t.Kind == TokenIdentifier {
+			name = t.Text
+		}
+	}
+	// "(" после имени - это функция или метод (const fn, final void f()), а не значение
+	if name != "" && s.at(s.indexAfter(i, name)).Text != "(" {
+		*target = append(*target, name)
+	}
+}
+
+// indexAfter возвращает индекс лексемы, следующей за первым вхождением имени после i
+func (s *frontendState) indexAfter(i int, name string) int {
+	for j := i + 1; j < len(s.tokens); j++ {
+		if s.tokens[j].Text == name {
+			return j + 1
+		}
+	}
+	return len(s.tokens)
+}
+
+// typedVariable распознает объявление переменной или параметра: "тип имя =",
+// "тип имя;", "тип[] имя", "List<T> имя", "тип *имя", "for (тип имя : ...)"
+func (s *frontendState) typedVariable(i int) bool {
+	switch s.at(i + 1).Text {
+	case "=", ";", ",", ")", "[", ":", "in":
+	default:
+		return false
+	}
+
+	prev := s.at(i - 1)
+	if prev.Text == "*" || prev.Text == "&" {
+		// "тип *имя" стоит в начале объявления, иначе это умножение: "a * b;"
+		before := s.at(i - 3)
+		if before.Pos.Line == prev.Pos.Line && before.Text != "::" && !declarationStart[before.Text] &&
+			!typeKeywords[before.Text] && !declarationModifiers[before.Text] && !s.spec.constantKeywords[before.Text] {
+			return false
+		}
+		prev = s.at(i - 2)
+	}
+	if prev.Kind != TokenIdentifier && !typeKeywords[prev.Text] && prev.Text != ">" && prev.Text != "]" {
+		return false
+	}
+
+	// Имена после const и final в том же объявлении уже учтены как константы
+	for j := i - 1; j >= 0 && !declarationStart[s.tokens[j].Text]; j-- {
+		if s.spec.constantKeywords[s.tokens[j].Text] {
+			return false
+		}
+	}
+	return true
+}
+
+// Лексемы, после которых может начинаться объявление переменной или параметра
+var declarationStart = keywordSet("; { } ( ,")
+
+// keywordFunction разбирает функцию, объявленную ключевым словом (def, fn, function)
+func (s *frontendState) keywordFunction(i int) {
+	j := i + 1
+	// Ruby: def self.name
+	if s.at(j).Text == "self" && s.at(j+1).Text == "." {
+		j += 2
+	}
+	// PHP: function &name
+	if s.at(j).Text == "&" {
+		j++
+	}
+	name := s.at(j)
+	if name.Kind != TokenIdentifier {
+		// Анонимная функция или оператор Ruby (def ==): тело в фигурных скобках
+		// откроет обычный блок, а в Ruby блок до end открывается здесь
+		if s.spec.endBlocks {
+			s.open(openBlock{})
+		}
+		return
+	}
+
+	// Параметры типа Rust: fn name<T: Display>(...)
+	open := j + 1
+	if s.at(open).Text == "<" {
+		for depth := 0; open < len(s.tokens); open++ {
+			if t := s.tokens[open].Text; t == "<" {
+				depth++
+			} else if t == ">" {
+				if depth--; depth == 0 {
+					open++
+					break
+				}
+			}
+		}
+	}
+
+	var params [][]Token
+	end := j
+	if s.at(open).Text == "(" {
+		params, end = s.parameters(open)
+	} else if s.spec.endBlocks {
+		// Ruby допускает параметры без скобок до конца строки
+		var current []Token
+		for end = j + 1; end < len(s.tokens) && s.tokens[end].Pos.Line == name.Pos.Line; end++ {
+			if s.tokens[end].Text == "," {
+				params = append(params, current)
+				current = nil
+				continue
+			}
+			current = append(current, s.tokens[end])
+		}
+		if len(current) > 0 {
+			params = append(params, current)
+		}
+		end--
+	}
+
+	returnType := ""
+	if s.spec.returnArrow != "" && s.at(end+1).Text == s.spec.returnArrow {
+		returnType = s.joinUntil(end+2, "{", ";", ":", "where")
+	}
+	s.addFunction(name.Text, params, returnType, i)
+}
+
+// typedFunction распознает функцию вида "тип имя(параметры) {": после скобок
+// допускаются const, override, throws и т.п., но не вызов внутри выражения
+func (s *frontendState) typedFunction(i int) bool {
+	if s.at(i+1).Text != "(" || s.at(i-1).Text == "new" || s.at(i-1).Text == "." {
+		return false
+	}
+	params, end := s.parameters(i + 1)
+	// Между скобками и телом допускаются только слова в той же строке:
+	// иначе "f()\ntry {" в JavaScript без точек с запятой приняли бы за функцию
+	j := end + 1
+	for ; j < len(s.tokens); j++ {
+		t := s.tokens[j]
+		if t.Pos.Line != s.tokens[end].Pos.Line || (t.Kind != TokenKeyword && t.Kind != TokenIdentifier && t.Text != "," && t.Text != ".") {
+			break
+		}
+	}
+	if s.at(j).Text != "{" {
+		return false
+	}
+
+	name := s.tokens[i].Text
+	if s.at(i-1).Text == "::" && s.at(i-2).Kind == TokenIdentifier {
+		name = s.at(i-2).Text + "::" + name
+	}
+
+	// Тип результата - лексемы перед именем в той же строке, без модификаторов
+	var returnType []string
+	start := i - 1
+	if strings.Contains(name, "::") {
+		start = i - 3
+	}
+	for k := start; k >= 0 && s.tokens[k].Pos.Line == s.tokens[i].Pos.Line; k-- {
+		t := s.tokens[k].Text
+		if t == ";" || t == "{" || t == "}" || t == ":" {
+			break
+		}
+		if !declarationModifiers[t] {
+			returnType = append([]string{t}, returnType...)
+		}
+	}
+
+	s.addFunction(name, params, strings.Join(returnType, " "), i)
+	return true
+}
+
+// parameters разбирает список параметров в скобках, открытых лексемой open,
+// и возвращает параметры и индекс закрывающей скобки. Запятые внутри
+// параметров шаблона (Map<K, V>) параметры не разделяют.
+func (s *frontendState) parameters(open int) ([][]Token, int) {
+	var params [][]Token
+	var current []Token
+	depth, angle := 0, 0
+	for i := open; i < len(s.tokens); i++ {
+		t := s.tokens[i]
+		switch t.Text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		case "<":
+			angle++
+		case ">":
+			angle = max(0, angle-1)
+		}
+		if depth == 0 {
+			if len(current) > 0 {
+				params = append(params, current)
+			}
+			return params, i
+		}
+		if i == open {
+			continue
+		}
+		if depth == 1 && angle == 0 && t.Text == "," {
+			params = append(params, current)
+			current = nil
+			continue
+		}
+		current = append(current, t)
+	}
+	return params, len(s.tokens) - 1
+}
+
+// joinUntil склеивает тексты лексем от start до первого из терминаторов
+func (s *frontendState) joinUntil(start int, terminators ...string) string {
+	var parts []string
+	for i := start; i < len(s.tokens); i++ {
+		if containsString(terminators, s.tokens[i].Text) {
+			break
+		}
+		parts = append(parts, s.tokens[i].Text)
+	}
+	return strings.Join(parts, " ")
+}
+
+// addFunction сохраняет функцию, объявленную лексемой decl; размер и отпечаток
+// тела станут известны при закрытии ее тела
+func (s *frontendState) addFunction(name string, params [][]Token, returnType string, decl int) {
+	fa := &s.result.Functions
+	fa.DeclareOrder = append(fa.DeclareOrder, name)
+	fa.ParamCount[name] = len(params)
+	fa.ReturnTypes[name] = returnType
+	if s.spec.typedParams {
+		fa.ParamTypes[name] = "(" + strings.Join(paramTypes(params), ", ") + ")"
+	}
+	s.result.Identifiers.Functions = append(s.result.Identifiers.Functions, name)
+
+	if s.spec.endBlocks {
+		s.open(openBlock{function: name, start: s.tokens[decl].Pos, bodyStart: decl})
+	} else {
+		s.pendingFunction, s.pendingPos = name, s.tokens[decl].Pos
+	}
+}
+
+// paramTypes оставляет от параметров только типы: без имен и значений по умолчанию.
+// Тип Rust идет после двоеточия, в остальных языках - перед именем.
+func paramTypes(params [][]Token) []string {
+	var types []string
+	for _, param := range params {
+		typed := false
+		for k, t := range param {
+			if t.Text == ":" {
+				param, typed = param[k+1:], true
+				break
+			}
+		}
+
+		var parts []string
+		for k, t := range param {
+			if t.Text == "=" {
+				break
+			}
+			last := k == len(param)-1 || param[k+1].Text == "="
+			if t.Kind == TokenIdentifier && (strings.HasPrefix(t.Text, "$") || (!typed && last)) {
+				continue
+			}
+			parts = append(parts, t.Text)
+		}
+		types = append(types, strings.Join(parts, " "))
+	}
+	return types
+}
+
+// importStatement разбирает импорт. Если в нем есть строка (JavaScript, PHP
+// require, Ruby, #include "файл"), импортом считается ее содержимое, иначе -
+// путь из лексем до ";" или конца строки (Java, C#, Rust, #include <файл>).
+func (s *frontendState) importStatement(i int) {
+	if s.spec.moduleImports {
+		s.moduleImport(i)
+		return
+	}
+	word := s.tokens[i].Text
+	next := s.at(i + 1)
+	switch {
+	case s.spec.preprocessor && s.at(i-1).Text != "#":
+		return
+	case word == "using" && next.Text == "(":
+		// C#: using (var x = ...) - оператор, а не импорт
+		return
+	case word == "use" && s.at(i-1).Text == ")":
+		// PHP: function () use ($x) - захват переменных замыканием
+		return
+	case word == "require" && next.Text != "(" && next.Kind != TokenString:
+		// JavaScript: require - обычное имя, если это не вызов
+		return
+	}
+
+	var path strings.Builder
+	line := s.tokens[i].Pos.Line
+	depth := 0
+	prevWord := false
+	for j := i + 1; j < len(s.tokens); j++ {
+		t := s.tokens[j]
+		if t.Text == ";" || (depth == 0 && t.Pos.Line != line) {
+			break
+		}
+		switch t.Text {
+		case "{":
+			depth++
+		case "}":
+			depth--
+		}
+		// import { a, b } from "x" может занимать несколько строк
+		line = t.Pos.Line
+
+		if t.Kind == TokenString {
+			s.result.Imports.ImportList = append(s.result.Imports.ImportList, strings.Trim(t.Text, "\"'`"))
+			return
+		}
+		if t.Text == "(" || t.Text == ")" {
+			continue
+		}
+		word := t.Kind == TokenKeyword || t.Kind == TokenIdentifier
+		if word && prevWord {
+			path.WriteString(" ")
+		}
+		path.WriteString(t.Text)
+		prevWord = word
+	}
+	if path.Len() > 0 {
+		s.result.Imports.ImportList = append(s.result.Imports.ImportList, path.String())
+	}
+}
+
+// moduleImport разбирает импорт Python: "import a.b as c, d" дает a.b и d,
+// "from a.b import c as e" - a.b.c. Запоминается и имя, под которым модуль
+// доступен в коде: c, d и e; без as у "import a.b" - a.
+func (s *frontendState) moduleImport(i int) {
+	// raise X from e, yield from - не импорты
+	if !s.startsStatement(i) {
+		return
+	}
+
+	j := i + 1
+	line := s.tokens[i].Pos.Line
+	prefix := ""
+	if s.tokens[i].Text == "from" {
+		for ; j < len(s.tokens) && s.tokens[j].Text != "import" && s.tokens[j].Pos.Line == line; j++ {
+			prefix += s.tokens[j].Text
+		}
+		if s.at(j).Text != "import" {
+			return
+		}
+		// from . import x - относительный импорт, точка уже есть
+		if !strings.HasSuffix(prefix, ".") {
+			prefix += "."
+		}
+		j++
+	}
+
+	var name, alias string
+	add := func() {
+		if name == "" {
+			return
+		}
+		path := prefix + name
+		s.result.Imports.ImportList = append(s.result.Imports.ImportList, path)
+		switch {
+		case alias != "":
+			s.imported[alias] = path
+		case prefix != "":
+			s.imported[name] = path
+		default:
+			first, _, _ := strings.Cut(name, ".")
+			s.imported[first] = path
+		}
+		name, alias = "", ""
+	}
+
+	depth := 0
+	for ; j < len(s.tokens); j++ {
+		t := s.tokens[j]
+		if t.Text == ";" || (depth == 0 && t.Pos.Line != line) {
+			break
+		}
+		// from a import (b,\n c) может занимать несколько строк
+		line = t.Pos.Line
+		switch t.Text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		case ",":
+			add()
+		case "as":
+			alias = s.at(j + 1).Text
+			j++
+		default:
+			name += t.Text
+		}
+	}
+	add()
+}
+
+// collectImportUsage собирает, какие члены импортированных модулей используются:
+// локальное имя импорта, за которым следует ".", "::" или "->" и имя
+func (s *frontendState) collectImportUsage() {
+	if s.spec.preprocessor {
+		// #include подключает файл, а не пространство имен
+		return
+	}
+	// Имена импортов Python известны из moduleImport, в остальных языках имя -
+	// последний сегмент пути
+	localNames := s.imported
+	if !s.spec.moduleImports {
+		for _, imp := range s.result.Imports.ImportList {
+			local := imp
+			for _, sep := range []string{"/", ".", "::", "\\"} {
+				if k := strings.LastIndex(local, sep); k >= 0 && k+len(sep) < len(local) {
+					local = local[k+len(sep):]
+				}
+			}
+			localNames[local] = imp
+		}
+	}
+
+	usages := make(map[string]map[string]bool)
+	for i, t := range s.tokens {
+		imp, ok := localNames[t.Text]
+		if !ok || t.Kind != TokenIdentifier || s.previousOnLine(i, s.spec.importKeywords) {
+			continue
+		}
+		sep, member := s.at(i+1), s.at(i+2)
+		if (sep.Text == "." || sep.Text == "::" || sep.Text == "->") && member.Kind == TokenIdentifier {
+			if usages[imp] == nil {
+				usages[imp] = make(map[string]bool)
+			}
+			usages[imp][member.Text] = true
+		}
+	}
+
+	for imp, names := range usages {
+		var list []string
+		for name := range names {
+			list = append(list, name)
+		}
+		sort.Strings(list)
+		s.result.Imports.UsagePatterns[imp] = strings.Join(list, ",")
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzeSourceTokens(t *testing.T) {
+	tests := []struct {
+		language  string
+		src       string
+		functions []string
+		params    map[string]int
+		sizes     map[string]int
+		imports   []string
+		classes   []string
+		control   ControlFlow // без ControlPattern
+	}{
+		{
+			language: "python",
+			src: `import os
+import numpy as np
+from collections import defaultdict, Counter as C
+
+def add(a, b):
+    return a + b
+
+class Stack:
+    def push(self, x):
+        for item in x:
+            if item:
+                self.items.append(item)
+        y = 1 if x else 2
+`,
+			functions: []string{"add", "push"},
+			params:    map[string]int{"add": 2, "push": 2},
+			sizes:     map[string]int{"add": 2, "push": 5},
+			imports:   []string{"os", "numpy", "collections.defaultdict", "collections.Counter"},
+			classes:   []string{"Stack"},
+			control:   ControlFlow{IfCount: 2, ForCount: 1, MaxNesting: 2},
+		},
+		{
+			language: "javascript",
+			src: `import { a,
+  b } from './util.js';
+const fs = require('fs');
+function sum(xs, init) {
+  for (let i = 0; i < xs.length; i++) { if (xs[i] > 0) { init += xs[i]; } }
+  return init;
+}
+class Foo {
+  bar(x) {
+    while (x > 0) { x--; }
+  }
+}
+`,
+			functions: []string{"sum", "bar"},
+			params:    map[string]int{"sum": 2, "bar": 1},
+			sizes:     map[string]int{"sum": 4, "bar": 3},
+			imports:   []string{"./util.js", "fs"},
+			classes:   []string{"Foo"},
+			control:   ControlFlow{IfCount: 1, ForCount: 1, WhileCount: 1, MaxNesting: 2},
+		},
+		{
+			language: "c",
+			src: `#include <stdio.h>
+#include "util.h"
+static int *make(int n, const char *name)
+{
+    for (int i = 0; i < n; i++) {
+        if (i % 2) { printf("%d", i); }
+    }
+    return NULL;
+}
+struct Point { int x; int y; };
+`,
+			functions: []string{"make"},
+			params:    map[string]int{"make": 2},
+			sizes:     map[string]int{"make": 7},
+			imports:   []string{"<stdio.h>", "util.h"},
+			classes:   []string{"Point"},
+			control:   ControlFlow{IfCount: 1, ForCount: 1, MaxNesting: 2},
+		},
+		{
+			language: "c#",
+			src: `using System;
+using System.Collections.Generic;
+public class Circle : IShape {
+  public static List<int> Evens(List<int> xs, int limit = 5) {
+    foreach (var x in xs) { if (x % 2 == 0) result.Add(x); }
+    using (var s = Open()) { }
+    return result;
+  }
+}
+`,
+			functions: []string{"Evens"},
+			params:    map[string]int{"Evens": 2},
+			sizes:     map[string]int{"Evens": 5},
+			imports:   []string{"System", "System.Collections.Generic"},
+			classes:   []string{"Circle"},
+			control:   ControlFlow{IfCount: 1, ForCount: 1, MaxNesting: 1},
+		},
+		{
+			language: "php",
+			src: `<?php
+use App\Models\User;
+require_once 'helpers.php';
+function total(array $items, int $tax = 0): int {
+    foreach ($items as $item) { if ($item > 5) { $sum += $item; } }
+    $f = function ($x) use ($sum) { return $x + $sum; };
+    return User::find($sum);
+}
+`,
+			functions: []string{"total"},
+			params:    map[string]int{"total": 2},
+			sizes:     map[string]int{"total": 5},
+			imports:   []string{`App\Models\User`, "helpers.php"},
+			control:   ControlFlow{IfCount: 1, ForCount: 1, MaxNesting: 2},
+		},
+		{
+			language: "ruby",
+			src: `require 'json'
+class Cart < Base
+  def total(tax = 0)
+    sum = 0
+    @items.each do |i|
+      sum += i if i > 0
+    end
+    unless sum > 100
+      sum += tax
+    end
+    sum
+  end
+end
+`,
+			functions: []string{"total"},
+			params:    map[string]int{"total": 1},
+			sizes:     map[string]int{"total": 10},
+			imports:   []string{"json"},
+			classes:   []string{"Cart"},
+			control:   ControlFlow{IfCount: 2, MaxNesting: 1},
+		},
+		{
+			language: "rust",
+			src: `use std::io;
+struct Point { x: i32, y: i32 }
+fn largest<T: PartialOrd>(list: &[T], n: usize) -> &T {
+    let mut best = &list[0];
+    for item in list { if item > best { best = item; } }
+    match n { 0 => {}, _ => {} }
+    best
+}
+`,
+			functions: []string{"largest"},
+			params:    map[string]int{"largest": 2},
+			sizes:     map[string]int{"largest": 6},
+			imports:   []string{"std::io"},
+			classes:   []string{"Point"},
+			control:   ControlFlow{IfCount: 1, ForCount: 1, SwitchCount: 1, MaxNesting: 2},
+		},
+		{
+			language: "java",
+			src: `import java.util.List;
+public class Main {
+  public static <T> List<T> take(List<T> xs, int n) throws Exception {
+    for (String s : xs) { if (s != null) n++; }
+    return List.of();
+  }
+}
+`,
+			functions: []string{"take"},
+			params:    map[string]int{"take": 2},
+			sizes:     map[string]int{"take": 4},
+			imports:   []string{"java.util.List"},
+			classes:   []string{"Main"},
+			control:   ControlFlow{IfCount: 1, ForCount: 1, MaxNesting: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		fa, ok := analyzeSourceTokens(tokenize(tt.src, tt.language), tt.language)
+		if !ok {
+			t.Errorf("%s: нет фронтенда", tt.language)
+			continue
+		}
+		if !reflect.DeepEqual(fa.Functions.DeclareOrder, tt.functions) {
+			t.Errorf("%s: функции %v, ожидалось %v", tt.language, fa.Functions.DeclareOrder, tt.functions)
+		}
+		if !reflect.DeepEqual(fa.Functions.ParamCount, tt.params) {
+			t.Errorf("%s: параметры %v, ожидалось %v", tt.language, fa.Functions.ParamCount, tt.params)
+		}
+		if !reflect.DeepEqual(fa.Functions.FunctionSizes, tt.sizes) {
+			t.Errorf("%s: размеры %v, ожидалось %v", tt.language, fa.Functions.FunctionSizes, tt.sizes)
+		}
+		if !reflect.DeepEqual(fa.Imports.ImportList, tt.imports) {
+			t.Errorf("%s: импорты %v, ожидалось %v", tt.language, fa.Imports.ImportList, tt.imports)
+		}
+		if !reflect.DeepEqual(fa.Identifiers.Classes, tt.classes) {
+			t.Errorf("%s: классы %v, ожидалось %v", tt.language, fa.Identifiers.Classes, tt.classes)
+		}
+		control := fa.ControlFlow
+		control.ControlPattern = ""
+		if control != tt.control {
+			t.Errorf("%s: поток управления %+v, ожидалось %+v", tt.language, control, tt.control)
+		}
+	}
+}
+
+func TestImportUsage(t *testing.T) {
+	tests := []struct {
+		language string
+		src      string
+		want     map[string]string
+	}{
+		{"python", "import numpy as np\nimport os.path\nx = np.sum(os.path.join(a))\n",
+			map[string]string{"numpy": "sum", "os.path": "path"}},
+		{"java", "import java.util.List;\nclass A { void f() { List.of(); } }\n",
+			map[string]string{"java.util.List": "of"}},
+		{"rust", "use std::io;\nfn main() { io::stdin(); }\n",
+			map[string]string{"std::io": "stdin"}},
+		{"c#", "using System;\nclass A { void F() { System.Console.WriteLine(); } }\n",
+			map[string]string{"System": "Console"}},
+	}
+	for _, tt := range tests {
+		fa, _ := analyzeSourceTokens(tokenize(tt.src, tt.language), tt.language)
+		if !reflect.DeepEqual(fa.Imports.UsagePatterns, tt.want) {
+			t.Errorf("%s: использование %v, ожидалось %v", tt.language, fa.Imports.UsagePatterns, tt.want)
+		}
+	}
+}
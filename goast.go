@@ -0,0 +1,371 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Размер n-граммы для сравнения структуры AST
+const astNGramSize = 6
+
+// goAnalysis результат разбора Go-файла через go/parser
+type goAnalysis struct {
+	Identifiers Identifiers
+	Functions   FunctionAnalysis
+	Imports     ImportAnalysis
+	ControlFlow ControlFlow
+	ASTShape    []string // типы узлов AST в порядке обхода, без имен и значений
+	Bodies      []FunctionFingerprint
+}
+
+// analyzeGoSource разбирает Go-файл и заполняет анализы по настоящему AST
+func analyzeGoSource(path, src string) (goAnalysis, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+	if err != nil {
+		return goAnalysis{}, fmt.Errorf("разбор %s: %w", path, err)
+	}
+
+	return goAnalysis{
+		Identifiers: goIdentifiers(file),
+		Functions:   goFunctions(fset, file),
+		Imports:     goImports(file),
+		ControlFlow: goControlFlow(file),
+		ASTShape:    goASTShape(file),
+		Bodies:      goFunctionFingerprints(fset, file),
+	}, nil
+}
+
+// goFuncName возвращает имя функции с учетом получателя: (*T).Method, T.Method
+func goFuncName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+
+	recv := fn.Recv.List[0].Type
+	pointer := false
+	if star, ok := recv.(*ast.StarExpr); ok {
+		pointer = true
+		recv = star.X
+	}
+	// У обобщенных типов отбрасываем параметры: T[K, V] -> T
+	switch expr := recv.(type) {
+	case *ast.IndexExpr:
+		recv = expr.X
+	case *ast.IndexListExpr:
+		recv = expr.X
+	}
+
+	name := types.ExprString(recv)
+	if pointer {
+		return "(*" + name + ")." + fn.Name.Name
+	}
+	return name + "." + fn.Name.Name
+}
+
+// fieldListString печатает список параметров или результатов с типами
+func fieldListString(fields *ast.FieldList) string {
+	if fields == nil {
+		return ""
+	}
+
+	var parts []string
+	for _, field := range fields.List {
+		typ := types.ExprString(field.Type)
+		if len(field.Names) == 0 {
+			parts = append(parts, typ)
+			continue
+		}
+		for _, name := range field.Names {
+			parts = append(parts, name.Name+" "+typ)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// fieldCount считает количество параметров (a, b int - это два параметра)
+func fieldCount(fields *ast.FieldList) int {
+	if fields == nil {
+		return 0
+	}
+	count := 0
+	for _, field := range fields.List {
+		if len(field.Names) == 0 {
+			count++
+		} else {
+			count += len(field.Names)
+		}
+	}
+	return count
+}
+
+// goFunctions собирает функции и методы с точными сигнатурами
+func goFunctions(fset *token.FileSet, file *ast.File) FunctionAnalysis {
+	fa := FunctionAnalysis{
+		ParamCount:    make(map[string]int),
+		ReturnTypes:   make(map[string]string),
+		FunctionSizes: make(map[string]int),
+		ParamTypes:    make(map[string]string),
+	}
+
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+
+		name := goFuncName(fn)
+		fa.DeclareOrder = append(fa.DeclareOrder, name)
+		fa.ParamCount[name] = fieldCount(fn.Type.Params)
+
+		params := "(" + fieldListString(fn.Type.Params) + ")"
+		if fn.Type.TypeParams != nil {
+			params = "[" + fieldListString(fn.Type.TypeParams) + "]" + params
+		}
+		fa.ParamTypes[name] = params
+
+		results := fieldListString(fn.Type.Results)
+		if fieldCount(fn.Type.Results) > 1 || strings.Contains(results, " ") {
+			results = "(" + results + ")"
+		}
+		fa.ReturnTypes[name] = results
+
+		start := fset.Position(fn.Pos()).Line
+		end := fset.Position(fn.End()).Line
+		fa.FunctionSizes[name] = end - start + 1
+	}
+
+	return fa
+}
+
+// goIdentifiers собирает объявленные имена
+func goIdentifiers(file *ast.File) Identifiers {
+	var ids Identifiers
+
+	addNames := func(target *[]string, exprs []ast.Expr) {
+		for _, expr := range exprs {
+			if ident, ok := expr.(*ast.Ident); ok && ident.Name != "_" {
+				*target = append(*target, ident.Name)
+			}
+		}
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.FuncDecl:
+			ids.Functions = append(ids.Functions, node.Name.Name)
+		case *ast.GenDecl:
+			for _, spec := range node.Specs {
+				switch s := spec.(type) {
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						if name.Name == "_" {
+							continue
+						}
+						if node.Tok == token.CONST {
+							ids.Constants = append(ids.Constants, name.Name)
+						} else {
+							ids.Variables = append(ids.Variables, name.Name)
+						}
+					}
+				case *ast.TypeSpec:
+					if _, ok := s.Type.(*ast.InterfaceType); ok {
+						ids.Interfaces = append(ids.Interfaces, s.Name.Name)
+					} else {
+						ids.Classes = append(ids.Classes, s.Name.Name)
+					}
+				}
+			}
+		case *ast.AssignStmt:
+			if node.Tok == token.DEFINE {
+				addNames(&ids.Variables, node.Lhs)
+			}
+		case *ast.RangeStmt:
+			if node.Tok == token.DEFINE {
+				addNames(&ids.Variables, []ast.Expr{node.Key, node.Value})
+			}
+		}
+		return true
+	})
+
+	return ids
+}
+
+// goImports собирает импорты и то, какие функции пакетов используются
+func goImports(file *ast.File) ImportAnalysis {
+	ia := ImportAnalysis{
+		UsagePatterns: make(map[string]string),
+	}
+
+	// Имя, под которым пакет доступен в коде
+	localNames := make(map[string]string)
+	for _, imp := range file.Imports {
+		entry := imp.Path.Value
+		path := strings.Trim(imp.Path.Value, "\"`")
+		local := goPackageName(path)
+		if imp.Name != nil {
+			entry = imp.Name.Name + " " + entry
+			local = imp.Name.Name
+		}
+		ia.ImportList = append(ia.ImportList, entry)
+		localNames[local] = path
+	}
+	ia.ImportOrder = strings.Join(ia.ImportList, ",")
+
+	usages := make(map[string]map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if pkg, ok := sel.X.(*ast.Ident); ok {
+			if path, imported := localNames[pkg.Name]; imported {
+				if usages[path] == nil {
+					usages[path] = make(map[string]bool)
+				}
+				usages[path][sel.Sel.Name] = true
+			}
+		}
+		return true
+	})
+
+	for path, names := range usages {
+		var list []string
+		for name := range names {
+			list = append(list, name)
+		}
+		sort.Strings(list)
+		ia.UsagePatterns[path] = strings.Join(list, ",")
+	}
+
+	return ia
+}
+
+// goPackageName угадывает имя пакета по пути импорта. Суффикс major-версии
+// модуля (math/rand/v2, gopkg.in/yaml.v3) в имя пакета не входит.
+func goPackageName(path string) string {
+	parts := strings.Split(path, "/")
+	name := parts[len(parts)-1]
+	if len(parts) > 1 && isMajorVersion(name) {
+		name = parts[len(parts)-2]
+	}
+	if strings.HasPrefix(path, "gopkg.in/") {
+		if i := strings.LastIndex(name, ".v"); i > 0 && isMajorVersion(name[i+1:]) {
+			name = name[:i]
+		}
+	}
+	return name
+}
+
+// isMajorVersion проверяет, что элемент пути - версия вида v2
+func isMajorVersion(elem string) bool {
+	if len(elem) < 2 || elem[0] != 'v' {
+		return false
+	}
+	for _, r := range elem[1:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// goControlFlow считает управляющие конструкции и глубину их вложенности
+func goControlFlow(file *ast.File) ControlFlow {
+	var cf ControlFlow
+	var pattern []string
+	var stack []ast.Node
+	depth := 0
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil {
+			// Выход из узла
+			last := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if isGoControlNode(last) {
+				depth--
+			}
+			return true
+		}
+		stack = append(stack, n)
+
+		switch n.(type) {
+		case *ast.IfStmt:
+			cf.IfCount++
+			pattern = append(pattern, "if")
+		case *ast.ForStmt, *ast.RangeStmt:
+			cf.ForCount++
+			pattern = append(pattern, "for")
+		case *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+			cf.SwitchCount++
+			pattern = append(pattern, "switch")
+		}
+
+		if isGoControlNode(n) {
+			depth++
+			cf.MaxNesting = max(cf.MaxNesting, depth)
+		}
+		return true
+	})
+
+	cf.ControlPattern = strings.Join(pattern, "->")
+	return cf
+}
+
+// isGoControlNode проверяет, является ли узел управляющей конструкцией
+func isGoControlNode(n ast.Node) bool {
+	switch n.(type) {
+	case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt,
+		*ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+		return true
+	}
+	return false
+}
+
+// goASTShape записывает типы узлов в порядке обхода.
+// Имена и значения литералов не попадают в последовательность, поэтому
+// она служит отпечатком структуры программы.
+func goASTShape(file *ast.File) []string {
+	var shape []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil {
+			return true
+		}
+		// Объявление пакета и импорты одинаковы у всех и не несут структуры
+		switch n.(type) {
+		case *ast.File:
+			return true
+		case *ast.ImportSpec:
+			return false
+		}
+		shape = append(shape, goNodeKind(n))
+		return true
+	})
+	return shape
+}
+
+// goNodeKind возвращает короткое имя типа узла: *ast.IfStmt -> IfStmt
+func goNodeKind(n ast.Node) string {
+	kind := reflect.TypeOf(n).Elem().Name()
+	// Для бинарных и унарных выражений важен оператор
+	switch node := n.(type) {
+	case *ast.BinaryExpr:
+		kind += node.Op.String()
+	case *ast.UnaryExpr:
+		kind += node.Op.String()
+	case *ast.AssignStmt:
+		kind += node.Tok.String()
+	}
+	return kind
+}
+
+// compareASTShapes сравнивает структуру AST двух проектов по n-граммам узлов
+func compareASTShapes(shape1, shape2 []string) float64 {
+	return ngramSimilarity(shape1, shape2, astNGramSize)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+const goastSample = `package main
+
+import (
+	"fmt"
+	str "strings"
+
+	"math/rand"
+)
+
+const (
+	A = iota
+	B
+)
+
+var (
+	x, y int
+	_    = 5
+)
+
+type Stack[T any] struct{ items []T }
+
+type Shape interface{ Area() float64 }
+
+func (s *Stack[T]) Push(v T) { s.items = append(s.items, v) }
+
+func (s Stack[T]) Len() int { return len(s.items) }
+
+func Map[K comparable, V any](m map[K]V, f func(V) V) (out map[K]V, err error) {
+	for k, v := range m {
+		if v2 := f(v); v2 != v {
+			switch {
+			case k == k:
+				fmt.Println(str.ToUpper("x"), rand.Intn(3))
+			}
+		}
+	}
+	return m, nil
+}
+`
+
+func TestAnalyzeGoSource(t *testing.T) {
+	ga, err := analyzeGoSource("main.go", goastSample)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fa := ga.Functions
+	if want := []string{"(*Stack).Push", "Stack.Len", "Map"}; !reflect.DeepEqual(fa.DeclareOrder, want) {
+		t.Errorf("функции %v, ожидалось %v", fa.DeclareOrder, want)
+	}
+	if want := map[string]int{"(*Stack).Push": 1, "Stack.Len": 0, "Map": 2}; !reflect.DeepEqual(fa.ParamCount, want) {
+		t.Errorf("параметры %v, ожидалось %v", fa.ParamCount, want)
+	}
+	if want := "[K comparable, V any](m map[K]V, f func(V) V)"; fa.ParamTypes["Map"] != want {
+		t.Errorf("типы параметров Map %q, ожидалось %q", fa.ParamTypes["Map"], want)
+	}
+	if want := map[string]string{"(*Stack).Push": "", "Stack.Len": "int", "Map": "(out map[K]V, err error)"}; !reflect.DeepEqual(fa.ReturnTypes, want) {
+		t.Errorf("результаты %v, ожидалось %v", fa.ReturnTypes, want)
+	}
+	if want := map[string]int{"(*Stack).Push": 1, "Stack.Len": 1, "Map": 11}; !reflect.DeepEqual(fa.FunctionSizes, want) {
+		t.Errorf("размеры %v, ожидалось %v", fa.FunctionSizes, want)
+	}
+
+	ids := ga.Identifiers
+	if want := []string{"A", "B"}; !reflect.DeepEqual(ids.Constants, want) {
+		t.Errorf("константы %v, ожидалось %v", ids.Constants, want)
+	}
+	if want := []string{"x", "y", "k", "v", "v2"}; !reflect.DeepEqual(ids.Variables, want) {
+		t.Errorf("переменные %v, ожидалось %v", ids.Variables, want)
+	}
+	if !reflect.DeepEqual(ids.Classes, []string{"Stack"}) || !reflect.DeepEqual(ids.Interfaces, []string{"Shape"}) {
+		t.Errorf("типы %v, интерфейсы %v", ids.Classes, ids.Interfaces)
+	}
+
+	imports := ga.Imports
+	if want := []string{`"fmt"`, `str "strings"`, `"math/rand"`}; !reflect.DeepEqual(imports.ImportList, want) {
+		t.Errorf("импорты %v, ожидалось %v", imports.ImportList, want)
+	}
+	if want := map[string]string{"fmt": "Println", "strings": "ToUpper", "math/rand": "Intn"}; !reflect.DeepEqual(imports.UsagePatterns, want) {
+		t.Errorf("использование %v, ожидалось %v", imports.UsagePatterns, want)
+	}
+
+	want := ControlFlow{IfCount: 1, ForCount: 1, SwitchCount: 1, MaxNesting: 3, ControlPattern: "for->if->switch"}
+	if ga.ControlFlow != want {
+		t.Errorf("поток управления %+v, ожидалось %+v", ga.ControlFlow, want)
+	}
+}
+
+func TestGoPackageName(t *testing.T) {
+	tests := map[string]string{
+		"fmt":                       "fmt",
+		"math/rand":                 "rand",
+		"math/rand/v2":              "rand",
+		"github.com/jackc/pgx/v5":   "pgx",
+		"gopkg.in/yaml.v3":          "yaml",
+		"example.com/v2":            "example.com",
+		"github.com/user/v2ray":     "v2ray",
+		"github.com/user/tool/vnet": "vnet",
+	}
+	for path, want := range tests {
+		if got := goPackageName(path); got != want {
+			t.Errorf("goPackageName(%q) = %q, ожидалось %q", path, got, want)
+		}
+	}
+}
+
+func TestGoImportsVersionedPath(t *testing.T) {
+	ga, err := analyzeGoSource("main.go", `package main
+
+import (
+	"math/rand/v2"
+
+	"gopkg.in/yaml.v3"
+)
+
+func main() { yaml.Marshal(rand.IntN(10)) }
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"math/rand/v2": "IntN", "gopkg.in/yaml.v3": "Marshal"}
+	if !reflect.DeepEqual(ga.Imports.UsagePatterns, want) {
+		t.Errorf("использование %v, ожидалось %v", ga.Imports.UsagePatterns, want)
+	}
+}
+
+func TestAnalyzeGoSourceSyntaxError(t *testing.T) {
+	if _, err := analyzeGoSource("bad.go", "package main\nfunc {"); err == nil {
+		t.Error("ошибка разбора не обнаружена")
+	}
+}
+
+func TestCompareASTShapesIgnoresNames(t *testing.T) {
+	shape := func(src string) []string {
+		ga, err := analyzeGoSource("main.go", src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ga.ASTShape
+	}
+	original := shape("package main\n\nfunc f(a, b int) int {\n\tif a > b {\n\t\treturn a - b\n\t}\n\treturn a + b\n}\n")
+	renamed := shape("package p\n\nfunc g(x, y int) int {\n\tif x > y {\n\t\treturn x - y\n\t}\n\treturn x + y\n}\n")
+	changed := shape("package main\n\nfunc f(a, b int) int {\n\tfor a > b {\n\t\ta = a * b\n\t}\n\treturn a / b\n}\n")
+
+	if s := compareASTShapes(original, renamed); s != 100 {
+		t.Errorf("переименованная копия: %.2f%%, ожидалось 100%%", s)
+	}
+	if s := compareASTShapes(original, changed); s >= 50 {
+		t.Errorf("другая функция: %.2f%%, ожидалось меньше 50%%", s)
+	}
+}
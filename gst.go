@@ -0,0 +1,171 @@
+package main
+
+import (
+	"hash/fnv"
+	"sort"
+)
+
+// Tile совпавший отрезок потоков токенов, найденный Greedy String Tiling
+type Tile struct {
+	Start1 int // начало отрезка в первом проекте
+	Start2 int // начало отрезка во втором проекте
+	Length int // длина отрезка в токенах
+}
+
+// Начальная длина поиска для Running Karp-Rabin
+const gstInitialSearchLength = 20
+
+// Основание полиномиального хеша Карпа-Рабина
+const karpRabinBase uint64 = 1000003
+
+// tokenIDs переводит токены в числа для хеширования
+func tokenIDs(tokens []string) []uint64 {
+	ids := make([]uint64, len(tokens))
+	for i, token := range tokens {
+		h := fnv.New64a()
+		h.Write([]byte(token))
+		ids[i] = h.Sum64()
+	}
+	return ids
+}
+
+// prefixHashes считает префиксные хеши, чтобы хеш любого окна получался за O(1)
+func prefixHashes(ids []uint64) []uint64 {
+	prefix := make([]uint64, len(ids)+1)
+	for i, id := range ids {
+		prefix[i+1] = prefix[i]*karpRabinBase + id
+	}
+	return prefix
+}
+
+// greedyStringTiling ищет максимальные непересекающиеся общие отрезки двух потоков
+// токенов длиной не меньше minMatch (алгоритм RKR-GST, Wise 1993)
+func greedyStringTiling(a, b []string, minMatch int) []Tile {
+	if minMatch < 1 {
+		minMatch = 1
+	}
+	if len(a) < minMatch || len(b) < minMatch {
+		return nil
+	}
+
+	idsA, idsB := tokenIDs(a), tokenIDs(b)
+	prefixA, prefixB := prefixHashes(idsA), prefixHashes(idsB)
+	markedA := make([]bool, len(a))
+	markedB := make([]bool, len(b))
+
+	// Степени основания для вычисления хеша окна
+	maxLen := max(len(a), len(b))
+	powers := make([]uint64, maxLen+1)
+	powers[0] = 1
+	for i := 1; i <= maxLen; i++ {
+		powers[i] = powers[i-1] * karpRabinBase
+	}
+	windowHash := func(prefix []uint64, start, length int) uint64 {
+		return prefix[start+length] - prefix[start]*powers[length]
+	}
+
+	// unmarkedRun возвращает длину неразмеченного участка, начинающегося с pos
+	unmarkedRun := func(marked []bool, pos int) int {
+		n := 0
+		for pos+n < len(marked) && !marked[pos+n] {
+			n++
+		}
+		return n
+	}
+
+	var tiles []Tile
+	searchLength := max(gstInitialSearchLength, minMatch)
+
+	for {
+		// scanpatterns: хешируем все неразмеченные окна второго потока
+		table := make(map[uint64][]int)
+		for start := 0; start < len(b); {
+			run := unmarkedRun(markedB, start)
+			if run == 0 {
+				start++
+				continue
+			}
+			for i := start; i+searchLength <= start+run; i++ {
+				h := windowHash(prefixB, i, searchLength)
+				table[h] = append(table[h], i)
+			}
+			start += run
+		}
+
+		var found []Tile
+		longest := 0
+		for start := 0; start < len(a); {
+			run := unmarkedRun(markedA, start)
+			if run == 0 {
+				start++
+				continue
+			}
+			for i := start; i+searchLength <= start+run; i++ {
+				for _, j := range table[windowHash(prefixA, i, searchLength)] {
+					// Проверяем совпадение и продлеваем его, пока токены равны
+					k := 0
+					for i+k < len(a) && j+k < len(b) && !markedA[i+k] && !markedB[j+k] && idsA[i+k] == idsB[j+k] {
+						k++
+					}
+					if k >= searchLength {
+						found = append(found, Tile{Start1: i, Start2: j, Length: k})
+						longest = max(longest, k)
+					}
+				}
+			}
+			start += run
+		}
+
+		// Найдено совпадение заметно длиннее окна - повторяем поиск с большим окном
+		if longest > 2*searchLength {
+			searchLength = longest
+			continue
+		}
+
+		// markstrings: размечаем совпадения от длинных к коротким, пропуская перекрытия
+		sort.SliceStable(found, func(i, j int) bool { return found[i].Length > found[j].Length })
+		for _, tile := range found {
+			if occluded(markedA, tile.Start1, tile.Length) || occluded(markedB, tile.Start2, tile.Length) {
+				continue
+			}
+			for k := 0; k < tile.Length; k++ {
+				markedA[tile.Start1+k] = true
+				markedB[tile.Start2+k] = true
+			}
+			tiles = append(tiles, tile)
+		}
+
+		if searchLength > 2*minMatch {
+			searchLength /= 2
+		} else if searchLength > minMatch {
+			searchLength = minMatch
+		} else {
+			break
+		}
+	}
+
+	sort.Slice(tiles, func(i, j int) bool { return tiles[i].Start1 < tiles[j].Start1 })
+	return tiles
+}
+
+// occluded проверяет, размечен ли хотя бы один токен отрезка
+func occluded(marked []bool, start, length int) bool {
+	for k := 0; k < length; k++ {
+		if marked[start+k] {
+			return true
+		}
+	}
+	return false
+}
+
+// tileCoverage возвращает долю токенов обоих потоков, покрытых отрезками
+func tileCoverage(tiles []Tile, len1, len2 int) float64 {
+	if len1+len2 == 0 {
+		return 0
+	}
+	covered := 0
+	for _, tile := range tiles {
+		covered += tile.Length
+	}
+	return float64(2*covered) / float64(len1+len2) * 100
+}
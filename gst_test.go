@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// distinctTokens возвращает n различных токенов с префиксом prefix
+func distinctTokens(prefix string, n int) []string {
+	tokens := make([]string, n)
+	for i := range tokens {
+		tokens[i] = fmt.Sprint(prefix, i)
+	}
+	return tokens
+}
+
+// concat склеивает потоки токенов
+func concat(parts ...[]string) []string {
+	var result []string
+	for _, part := range parts {
+		result = append(result, part...)
+	}
+	return result
+}
+
+func TestGreedyStringTiling(t *testing.T) {
+	x, y := distinctTokens("x", 8), distinctTokens("y", 6)
+	long := distinctTokens("l", 60)
+	tests := []struct {
+		name     string
+		a, b     []string
+		minMatch int
+		want     []Tile
+	}{
+		{"одинаковые", x, x, 4, []Tile{{0, 0, 8}}},
+		{"переставленные блоки", concat(x, y), concat(y, x), 4, []Tile{{0, 6, 8}, {8, 0, 6}}},
+		{"вставка в середину", concat(x, distinctTokens("n", 3), y), concat(x, y), 4, []Tile{{0, 0, 8}, {11, 8, 6}}},
+		{"совпадение короче минимума", concat(x[:3], y), concat(distinctTokens("n", 5), x[:3]), 4, nil},
+		{"поток короче минимума", x[:3], x[:3], 4, nil},
+		{"повтор сопоставляется один раз", concat(x, x), x, 4, []Tile{{0, 0, 8}}},
+		{"длинное совпадение - один отрезок", concat(y, long), concat(long, x), 5, []Tile{{6, 0, 60}}},
+	}
+	for _, tt := range tests {
+		got := greedyStringTiling(tt.a, tt.b, tt.minMatch)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: %v, ожидалось %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTileCoverage(t *testing.T) {
+	tests := []struct {
+		tiles      []Tile
+		len1, len2 int
+		want       float64
+	}{
+		{[]Tile{{0, 0, 8}}, 8, 8, 100},
+		{[]Tile{{0, 0, 8}, {11, 8, 6}}, 17, 23, 70},
+		{nil, 10, 10, 0},
+		{nil, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := tileCoverage(tt.tiles, tt.len1, tt.len2); got != tt.want {
+			t.Errorf("tileCoverage(%v, %d, %d) = %.2f, ожидалось %.2f", tt.tiles, tt.len1, tt.len2, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+// Отпечаток, который есть больше чем в этой доле работ, - общая заготовка
+// (public static void main), а не признак списывания. Такие отпечатки не
+// разворачиваются в пары: иначе один отпечаток всей группы дает все N² пар
+// и индекс ничего не отсеивает.
+const indexMaxDocFraction = 0.5
+
+// В небольших группах частые отпечатки учитываются: пар там немного, а доля
+// от нескольких работ слишком груба
+const indexMinCappedPosting = 10
+
+// fingerprintIndex инвертированный индекс: отпечаток -> номера проектов, в которых он есть
+type fingerprintIndex map[uint64][]int
+
+// buildFingerprintIndex строит индекс отпечатков всех проектов. Каждый проект
+// попадает в список отпечатка не больше одного раза.
+func buildFingerprintIndex(projects []Project) fingerprintIndex {
+	index := make(fingerprintIndex)
+	for i, p := range projects {
+		seen := make(map[uint64]bool)
+		for _, fp := range p.Fingerprints {
+			if !seen[fp.Hash] {
+				seen[fp.Hash] = true
+				index[fp.Hash] = append(index[fp.Hash], i)
+			}
+		}
+	}
+	return index
+}
+
+// buildIRIndex строит индекс k-грамм промежуточного представления длиной
+// irMinMatch для отбора пар на разных языках. Совпадения короче irMinMatch при
+// сравнении IR не учитываются, поэтому у пары без общих k-грамм схожесть 0.
+func buildIRIndex(projects []Project) fingerprintIndex {
+	index := make(fingerprintIndex)
+	for i, p := range projects {
+		seen := make(map[uint64]bool)
+		for _, hash := range kGramHashes(p.Tokens.IR, irMinMatch) {
+			if !seen[hash] {
+				seen[hash] = true
+				index[hash] = append(index[hash], i)
+			}
+		}
+	}
+	return index
+}
+
+// sharedFingerprints считает число общих различных отпечатков для каждой пары
+// проектов (i, j), i < j, из projects работ. Отпечатки, которые есть больше чем
+// в indexMaxDocFraction работ, не учитываются. Пары без общих отпечатков в
+// результат не попадают.
+func (index fingerprintIndex) sharedFingerprints(projects int) map[[2]int]int {
+	limit := max(indexMinCappedPosting, int(indexMaxDocFraction*float64(projects)))
+	shared := make(map[[2]int]int)
+	for _, posting := range index {
+		if len(posting) > limit {
+			continue
+		}
+		// Списки упорядочены по номеру проекта, так как проекты обходятся по порядку
+		for a := 0; a < len(posting); a++ {
+			for b := a + 1; b < len(posting); b++ {
+				shared[[2]int{posting[a], posting[b]}]++
+			}
+		}
+	}
+	return shared
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// indexProject работа только с отпечатками, для проверки отбора пар
+func indexProject(name string, hashes ...uint64) Project {
+	p := Project{Name: name}
+	p.Language = "golang"
+	for i, h := range hashes {
+		p.Fingerprints = append(p.Fingerprints, Fingerprint{Hash: h, Pos: i})
+	}
+	return p
+}
+
+// archived переносит работу в архив прошлых лет: отбор по индексу касается
+// только пар с архивными работами
+func archived(p Project) Project {
+	p.ArchiveYear = 2020
+	return p
+}
+
+func TestSharedFingerprintsSkipsBoilerplate(t *testing.T) {
+	// Отпечаток 1 есть во всех работах, отпечаток 100 - только в первых двух.
+	// Первая работа из текущего запуска, остальные - из архива.
+	var projects []Project
+	for i := 0; i < 40; i++ {
+		hashes := []uint64{1, uint64(1000 + i)}
+		if i < 2 {
+			hashes = append(hashes, 100)
+		}
+		p := indexProject(fmt.Sprint("s", i), hashes...)
+		if i > 0 {
+			p = archived(p)
+		}
+		projects = append(projects, p)
+	}
+
+	shared := buildFingerprintIndex(projects).sharedFingerprints(len(projects))
+	if len(shared) != 1 || shared[[2]int{0, 1}] != 1 {
+		t.Fatalf("общие отпечатки: %v, ожидалась одна пара (0, 1)", shared)
+	}
+
+	jobs, stats := comparisonPairs(projects, 1, false)
+	if len(jobs) != 1 || jobs[0].i != 0 || jobs[0].j != 1 {
+		t.Errorf("к сравнению отобраны %v, ожидалась пара (0, 1)", jobs)
+	}
+	if stats.Pruned != stats.TotalPairs-1 {
+		t.Errorf("отсеяно %d из %d пар, ожидалось %d", stats.Pruned, stats.TotalPairs, stats.TotalPairs-1)
+	}
+}
+
+func TestComparisonPairsKeepsCurrentRun(t *testing.T) {
+	// У работ текущего запуска нет общих отпечатков, но матрица когорты должна быть полной
+	projects := []Project{indexProject("a", 1), indexProject("b", 2), indexProject("c", 3), archived(indexProject("old", 4))}
+	jobs, stats := comparisonPairs(projects, 1, false)
+	if len(jobs) != 3 || stats.Pruned != 3 || stats.TotalPairs != 6 {
+		t.Errorf("отобрано %v, статистика %+v; ожидались три пары текущего запуска", jobs, stats)
+	}
+	for _, job := range jobs {
+		if projects[job.i].ArchiveYear != 0 || projects[job.j].ArchiveYear != 0 {
+			t.Errorf("отобрана пара с архивной работой без общих отпечатков: %v", job)
+		}
+	}
+}
+
+func TestSharedFingerprintsKeepsSmallGroups(t *testing.T) {
+	// В маленькой группе общий для всех отпечаток - еще не заготовка
+	projects := []Project{indexProject("a", 1, 2), indexProject("b", 1, 3), indexProject("c", 1, 4)}
+	shared := buildFingerprintIndex(projects).sharedFingerprints(len(projects))
+	if len(shared) != 3 {
+		t.Errorf("общие отпечатки: %v, ожидались все три пары", shared)
+	}
+}
+
+// Гарантия отбора: пара отсеивается, только если у нее меньше minShared общих
+// отпечатков, которые не относятся к заготовкам
+func TestComparisonPairsNeverPrunesSharingPairs(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	const n, minShared = 60, 2
+	var projects []Project
+	sets := make([]map[uint64]bool, n)
+	for i := 0; i < n; i++ {
+		sets[i] = make(map[uint64]bool)
+		var hashes []uint64
+		for k := 0; k < 30; k++ {
+			h := uint64(rng.Intn(400))
+			if k < 3 {
+				h = uint64(10000 + k) // заготовка во всех работах
+			}
+			sets[i][h] = true
+			hashes = append(hashes, h)
+		}
+		p := indexProject(fmt.Sprint("s", i), hashes...)
+		if i%3 != 0 {
+			p = archived(p)
+		}
+		projects = append(projects, p)
+	}
+
+	docs := make(map[uint64]int)
+	for _, set := range sets {
+		for h := range set {
+			docs[h]++
+		}
+	}
+	limit := max(indexMinCappedPosting, int(indexMaxDocFraction*n))
+
+	jobs, stats := comparisonPairs(projects, minShared, false)
+	kept := make(map[[2]int]bool)
+	for _, job := range jobs {
+		kept[[2]int{job.i, job.j}] = true
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if projects[i].ArchiveYear != 0 && projects[j].ArchiveYear != 0 {
+				continue
+			}
+			current := projects[i].ArchiveYear == 0 && projects[j].ArchiveYear == 0
+			common := 0
+			for h := range sets[i] {
+				if sets[j][h] && docs[h] <= limit {
+					common++
+				}
+			}
+			if (current || common >= minShared) != kept[[2]int{i, j}] {
+				t.Fatalf("пара (%d, %d): общих отпечатков %d, отобрана %v", i, j, common, kept[[2]int{i, j}])
+			}
+		}
+	}
+	if stats.Compared+stats.Pruned != stats.TotalPairs {
+		t.Errorf("сравнено %d + отсеяно %d != всего %d", stats.Compared, stats.Pruned, stats.TotalPairs)
+	}
+}
+
+// irProject работа с промежуточным представлением исходного текста
+func irProject(name, language, src string) Project {
+	p := Project{Name: name}
+	p.Language = language
+	p.Tokens.IR = buildIR(tokenize(src, language), language)
+	return p
+}
+
+func TestComparisonPairsPrunesCrossLanguage(t *testing.T) {
+	projects := []Project{
+		irProject("port.py", "python", irMaxPy),
+		archived(irProject("port.java", "java", irMaxJava)),
+		archived(irProject("bank.java", "java", irBankJava)),
+		irProject("grades.py", "python", irGradesPy),
+	}
+
+	jobs, stats := comparisonPairs(projects, 1, true)
+	kept := make(map[[2]int]bool)
+	for _, job := range jobs {
+		kept[[2]int{job.i, job.j}] = true
+	}
+	if !kept[[2]int{0, 1}] {
+		t.Errorf("перенос решения отсеян: отобраны %v", jobs)
+	}
+	if stats.CrossPairs != 4 {
+		t.Errorf("пар на разных языках %d, ожидалось 4", stats.CrossPairs)
+	}
+
+	// Гарантия отбора: у отсеянной пары на разных языках схожесть IR равна 0
+	for i := range projects {
+		for j := i + 1; j < len(projects); j++ {
+			if projects[i].Language == projects[j].Language || kept[[2]int{i, j}] {
+				continue
+			}
+			if s, _, _ := compareIR(projects[i].Tokens.IR, projects[j].Tokens.IR); s != 0 {
+				t.Errorf("отсеяна пара (%s, %s) со схожестью %.1f%%", projects[i].Name, projects[j].Name, s)
+			}
+		}
+	}
+	if stats.Pruned == 0 {
+		t.Errorf("ни одна пара не отсеяна: %+v", stats)
+	}
+
+	if _, stats := comparisonPairs(projects, 0, true); stats.Pruned != 0 || stats.Compared != stats.TotalPairs {
+		t.Errorf("при minShared 0 отсеяно %d пар", stats.Pruned)
+	}
+}
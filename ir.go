@@ -0,0 +1,315 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Операции языконезависимого промежуточного представления (IR). Имена, литералы
+// и разделители отбрасываются: остаются управляющие конструкции, виды операций
+// и формы вызовов, одинаковые для построчного переноса решения на другой язык.
+const (
+	irFunc     = "FUNC"
+	irClass    = "CLASS"
+	irIf       = "IF"
+	irElse     = "ELSE"
+	irLoop     = "LOOP"
+	irSwitch   = "SWITCH"
+	irCase     = "CASE"
+	irReturn   = "RETURN"
+	irBreak    = "BREAK"
+	irContinue = "CONTINUE"
+	irTry      = "TRY"
+	irCatch    = "CATCH"
+	irFinally  = "FINALLY"
+	irThrow    = "THROW"
+	irNew      = "NEW"
+	irAssign   = "ASSIGN"
+	irUpdate   = "UPDATE" // составное присваивание, инкремент и декремент
+	irArith    = "ARITH"
+	irCompare  = "COMPARE"
+	irLogic    = "LOGIC"
+	irNot      = "NOT"
+	irBit      = "BIT"
+	irIndex    = "INDEX"
+	irCall     = "CALL" // дополняется числом аргументов: CALL/2
+)
+
+// Минимальная длина совпадения при межъязыковом сравнении в операциях IR.
+// IR короче потока токенов, поэтому порог меньше MinMatchLength.
+const irMinMatch = 5
+
+// irOperators переводит операторы всех языков в виды операций
+var irOperators = map[string]string{
+	"=": irAssign, ":=": irAssign,
+	"+=": irUpdate, "-=": irUpdate, "*=": irUpdate, "/=": irUpdate, "%=": irUpdate, "**=": irUpdate,
+	"//=": irUpdate, "&=": irUpdate, "|=": irUpdate, "^=": irUpdate, "<<=": irUpdate, ">>=": irUpdate,
+	">>>=": irUpdate, "&^=": irUpdate, "++": irUpdate, "--": irUpdate,
+	"+": irArith, "-": irArith, "*": irArith, "/": irArith, "%": irArith, "**": irArith, "//": irArith,
+	"==": irCompare, "!=": irCompare, "<": irCompare, ">": irCompare, "<=": irCompare, ">=": irCompare,
+	"===": irCompare, "!==": irCompare, "<=>": irCompare,
+	"&&": irLogic, "||": irLogic,
+	"!": irNot,
+	"&": irBit, "|": irBit, "^": irBit, "~": irBit, "<<": irBit, ">>": irBit, "&^": irBit,
+}
+
+// irFrontend описывает перевод лексем одного языка в IR
+type irFrontend struct {
+	keywords       map[string][]string // ключевое слово -> операции IR
+	typedFunctions bool                // функции объявляются без ключевого слова: "тип имя(...) {"
+	ignoredCalls   map[string]bool     // вызовы длины и диапазонов, которые в других языках - свойства или синтаксис
+}
+
+// irKeywords разбирает таблицу вида "if=IF elif=ELSE,IF"
+func irKeywords(spec string) map[string][]string {
+	table := make(map[string][]string)
+	for _, entry := range strings.Fields(spec) {
+		word, ops, _ := strings.Cut(entry, "=")
+		table[word] = strings.Split(ops, ",")
+	}
+	return table
+}
+
+// Заголовки циклов перебора. Заголовок целиком сводится к одной операции LOOP:
+// "for i in range(len(a))", "for (int i = 0; i < n; i++)" и "for _, x := range a"
+// записывают один и тот же перебор разным синтаксисом.
+var irLoopHeaders = keywordSet("for foreach")
+
+// irIterationWords отличают заголовок перебора от цикла Go с одним условием
+// "for lo <= hi {", который записывает while и сохраняет условие
+var irIterationWords = keywordSet("; : in of as range")
+
+// Ключевые слова, общие для языков с синтаксисом C
+const cStyleIRKeywords = `if=IF else=ELSE for=LOOP while=LOOP do=LOOP switch=SWITCH case=CASE default=CASE
+	return=RETURN break=BREAK continue=CONTINUE try=TRY catch=CATCH finally=FINALLY throw=THROW new=NEW`
+
+// Фронтенды IR для всех языков из supportedExtensions
+var irFrontends = map[string]irFrontend{
+	"golang": {
+		keywords: irKeywords(`func=FUNC if=IF else=ELSE for=LOOP switch=SWITCH select=SWITCH case=CASE
+			default=CASE return=RETURN break=BREAK continue=CONTINUE struct=CLASS`),
+		ignoredCalls: keywordSet("len cap"),
+	},
+	"python": {
+		keywords: irKeywords(`def=FUNC lambda=FUNC class=CLASS if=IF elif=ELSE,IF else=ELSE for=LOOP while=LOOP
+			return=RETURN break=BREAK continue=CONTINUE try=TRY except=CATCH finally=FINALLY raise=THROW
+			and=LOGIC or=LOGIC not=NOT`),
+		ignoredCalls: keywordSet("len range enumerate"),
+	},
+	"java": {
+		keywords:       irKeywords(cStyleIRKeywords + ` class=CLASS`),
+		typedFunctions: true,
+		ignoredCalls:   keywordSet("size length"),
+	},
+	"javascript": {
+		keywords: irKeywords(cStyleIRKeywords + ` function=FUNC class=CLASS`),
+	},
+	"c": {
+		keywords:       irKeywords(cStyleIRKeywords),
+		typedFunctions: true,
+		ignoredCalls:   keywordSet("strlen"),
+	},
+	"c++": {
+		keywords:       irKeywords(cStyleIRKeywords + ` class=CLASS struct=CLASS`),
+		typedFunctions: true,
+		ignoredCalls:   keywordSet("size length strlen"),
+	},
+	"c#": {
+		keywords:       irKeywords(cStyleIRKeywords + ` foreach=LOOP class=CLASS struct=CLASS`),
+		typedFunctions: true,
+	},
+	"php": {
+		keywords: irKeywords(cStyleIRKeywords + ` function=FUNC fn=FUNC class=CLASS foreach=LOOP elseif=ELSE,IF
+			match=SWITCH and=LOGIC or=LOGIC xor=LOGIC`),
+		ignoredCalls: keywordSet("count strlen range"),
+	},
+	"ruby": {
+		keywords: irKeywords(`def=FUNC class=CLASS if=IF unless=IF,NOT elsif=ELSE,IF else=ELSE while=LOOP
+			until=LOOP,NOT for=LOOP case=SWITCH when=CASE return=RETURN break=BREAK next=CONTINUE
+			begin=TRY rescue=CATCH ensure=FINALLY and=LOGIC or=LOGIC not=NOT`),
+		ignoredCalls: keywordSet("size length"),
+	},
+	"rust": {
+		keywords: irKeywords(`fn=FUNC struct=CLASS if=IF else=ELSE for=LOOP while=LOOP loop=LOOP match=SWITCH
+			return=RETURN break=BREAK continue=CONTINUE`),
+		ignoredCalls: keywordSet("len"),
+	},
+}
+
+// buildIR переводит лексемы файла в последовательность операций IR
+func buildIR(tokens []Token, language string) []string {
+	frontend, ok := irFrontends[language]
+	if !ok {
+		return nil
+	}
+
+	var ir []string
+	signatureLine := 0 // строка заголовка функции: скобки в ней - параметры, а не вызов
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		switch token.Kind {
+		case TokenKeyword:
+			ops := frontend.keywords[token.Text]
+			if len(ops) > 0 && ops[0] == irFunc {
+				signatureLine = token.Pos.Line
+			}
+			ir = append(ir, ops...)
+			if irLoopHeaders[token.Text] {
+				if end := loopHeaderEnd(tokens, i); iterationHeader(tokens[i+1 : end+1]) {
+					i = end
+				}
+			}
+
+		case TokenOperator:
+			if op, ok := irOperators[token.Text]; ok {
+				ir = append(ir, op)
+			}
+
+		case TokenPunctuation:
+			// Индексация: скобка сразу после имени или другого выражения, но не
+			// пустые скобки типа массива "int[] a"
+			if token.Text == "[" && i > 0 && (tokens[i-1].Kind == TokenIdentifier || tokens[i-1].Text == ")" || tokens[i-1].Text == "]") &&
+				(i+1 >= len(tokens) || tokens[i+1].Text != "]") {
+				ir = append(ir, irIndex)
+			}
+
+		case TokenIdentifier:
+			if i+1 >= len(tokens) || tokens[i+1].Text != "(" || token.Pos.Line == signatureLine {
+				continue
+			}
+			args, end := callArguments(tokens, i+1)
+			if frontend.typedFunctions && end+1 < len(tokens) && tokens[end+1].Text == "{" {
+				ir = append(ir, irFunc)
+				signatureLine = token.Pos.Line
+				continue
+			}
+			if frontend.ignoredCalls[token.Text] {
+				continue
+			}
+			ir = append(ir, irCall+"/"+strconv.Itoa(args))
+		}
+	}
+	return ir
+}
+
+// loopHeaderEnd возвращает индекс последней лексемы заголовка цикла, начатого
+// ключевым словом for: закрывающей скобки "for (...)", а без скобок - лексемы
+// перед "{", ":", "do", внешней закрывающей скобкой или концом строки
+func loopHeaderEnd(tokens []Token, loop int) int {
+	if loop+1 < len(tokens) && tokens[loop+1].Text == "(" {
+		_, end := callArguments(tokens, loop+1)
+		return end
+	}
+	depth := 0
+	for i := loop + 1; i < len(tokens); i++ {
+		switch tokens[i].Text {
+		case "(", "[":
+			depth++
+		case ")", "]":
+			depth--
+			if depth < 0 { // генератор списка: "[x for x in xs]"
+				return i - 1
+			}
+		case "{", ":", "do":
+			if depth == 0 {
+				return i - 1
+			}
+		}
+		if depth == 0 && i+1 < len(tokens) && tokens[i+1].Pos.Line != tokens[i].Pos.Line {
+			return i
+		}
+	}
+	return len(tokens) - 1
+}
+
+// iterationHeader сообщает, перебирает ли заголовок цикла счётчик или коллекцию
+func iterationHeader(header []Token) bool {
+	for _, token := range header {
+		if irIterationWords[token.Text] {
+			return true
+		}
+	}
+	return false
+}
+
+// callArguments считает аргументы в скобках, открытых лексемой open, и
+// возвращает их число и индекс закрывающей скобки
+func callArguments(tokens []Token, open int) (int, int) {
+	depth, commas := 0, 0
+	for i := open; i < len(tokens); i++ {
+		switch tokens[i].Text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+			if depth == 0 {
+				if i == open+1 {
+					return 0, i
+				}
+				return commas + 1, i
+			}
+		case ",":
+			if depth == 1 {
+				commas++
+			}
+		}
+	}
+	return commas + 1, len(tokens) - 1
+}
+
+// compareIR сравнивает промежуточные представления двух работ на разных языках
+// с помощью Greedy String Tiling: оценка - покрытие обоих представлений совпавшими
+// отрезками, а доли вхождения считаются для каждой работы отдельно
+func compareIR(ir1, ir2 []string) (similarity, containment1, containment2 float64) {
+	tiles := greedyStringTiling(ir1, ir2, irMinMatch)
+	containment1, containment2 = containment(nil, tiles, 0, len(ir1), len(ir2))
+	return tileCoverage(tiles, len(ir1), len(ir2)), containment1, containment2
+}
+
+// compareCrossLanguage сравнивает работы на разных языках по промежуточному
+// представлению. Остальные метрики зависят от синтаксиса языка и не считаются.
+func compareCrossLanguage(p1, p2 Project) ComparisonResult {
+	result := ComparisonResult{
+		Project1:      p1.Name,
+		Project2:      p2.Name,
+		Language:      p1.Language + "/" + p2.Language,
+		ArchiveYear:   max(p1.ArchiveYear, p2.ArchiveYear),
+		CrossLanguage: true,
+		Unavailable:   append([]string(nil), allMetrics...),
+	}
+	result.IRSimilarity, result.Containment1, result.Containment2 = compareIR(p1.Tokens.IR, p2.Tokens.IR)
+
+	// Файлы тоже сравниваются по промежуточному представлению. Пары файлов без
+	// общих k-грамм IR не сравниваются: совпадений длиной irMinMatch у них нет.
+	result.Files1, result.Files2, result.FileMatrix = compareFiles(p1, p2)
+	grams2 := make([]map[uint64]bool, len(p2.Files))
+	for j, file := range p2.Files {
+		grams2[j] = make(map[uint64]bool)
+		for _, hash := range kGramHashes(file.Tokens.IR, irMinMatch) {
+			grams2[j][hash] = true
+		}
+	}
+	for i, row := range result.FileMatrix {
+		grams1 := kGramHashes(p1.Files[i].Tokens.IR, irMinMatch)
+		for j := range row {
+			row[j] = 0
+			if sharesGram(grams1, grams2[j]) {
+				row[j], _, _ = compareIR(p1.Files[i].Tokens.IR, p2.Files[j].Tokens.IR)
+			}
+		}
+	}
+	result.Paths1, result.Paths2 = p1.filePaths(), p2.filePaths()
+
+	result.OverallScore = config.Scoring.overallScore(result)
+	return result
+}
+
+// sharesGram сообщает, есть ли среди hashes хотя бы одна k-грамма из set
+func sharesGram(hashes []uint64, set map[uint64]bool) bool {
+	for _, hash := range hashes {
+		if set[hash] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildIR(t *testing.T) {
+	tests := []struct {
+		language string
+		src      string
+		want     string
+	}{
+		{"python", "def add(a, b):\n    return a + b\n", "FUNC RETURN ARITH"},
+		{"java", "int add(int a, int b) {\n  return a + b;\n}", "FUNC RETURN ARITH"},
+		{"javascript", "if (!ok) { x = f(a, b); }", "IF NOT ASSIGN CALL/2"},
+		{"golang", "x[i] += y", "INDEX UPDATE"},
+		{"ruby", "unless done\n  y = z\nend\n", "IF NOT ASSIGN"},
+		{"php", "try { throw new E(); } catch (E $e) { }", "TRY THROW NEW CALL/0 CATCH"},
+		{"python", "x = 1", "ASSIGN"},
+		{"cobol", "x = 1", ""},
+	}
+	for _, tt := range tests {
+		got := strings.Join(buildIR(tokenize(tt.src, tt.language), tt.language), " ")
+		if got != tt.want {
+			t.Errorf("%s %q: IR %q, ожидалось %q", tt.language, tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestBuildIRLoopHeaders(t *testing.T) {
+	tests := []struct {
+		language string
+		src      string
+		want     string
+	}{
+		{"python", "for i in range(len(a)):\n    x = a[i]\n", "LOOP ASSIGN INDEX"},
+		{"java", "for (int i = 0; i < a.length; i++) { x = a[i]; }", "LOOP ASSIGN INDEX"},
+		{"java", "for (String s : xs) { x = s; }", "LOOP ASSIGN"},
+		{"c", "for (;;) { x = a[i]; }", "LOOP ASSIGN INDEX"},
+		{"golang", "for i := range a { x = a[i] }", "LOOP ASSIGN INDEX"},
+		{"golang", "for i := 0; i < len(a); i++ { x = a[i] }", "LOOP ASSIGN INDEX"},
+		{"golang", "for lo <= hi { lo++ }", "LOOP COMPARE UPDATE"},
+		{"javascript", "for (const x of xs) { y = x; }", "LOOP ASSIGN"},
+		{"rust", "for x in list.iter() { y = x; }", "LOOP ASSIGN"},
+		{"ruby", "for x in xs do\n  y = x\nend\n", "LOOP ASSIGN"},
+		{"php", "foreach ($items as $item) { $y = $item; }", "LOOP ASSIGN"},
+		{"python", "while i < len(a):\n    i += 1\n", "LOOP COMPARE UPDATE"},
+		{"python", "y = [f(x) for x in xs]\n", "ASSIGN CALL/1 LOOP"},
+		{"java", "void f(String[] args) {\n  g(args[0]);\n}", "FUNC CALL/1 INDEX"},
+	}
+	for _, tt := range tests {
+		got := strings.Join(buildIR(tokenize(tt.src, tt.language), tt.language), " ")
+		if got != tt.want {
+			t.Errorf("%s %q: IR %q, ожидалось %q", tt.language, tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestCallArguments(t *testing.T) {
+	tests := []struct {
+		src        string
+		args, last int
+	}{
+		{"f()", 0, 2},
+		{"f(a)", 1, 3},
+		{"f(a, g(b, c), [d, e])", 3, 16},
+	}
+	for _, tt := range tests {
+		args, last := callArguments(tokenize(tt.src, "python"), 1)
+		if args != tt.args || last != tt.last {
+			t.Errorf("%q: %d аргументов до %d, ожидалось %d до %d", tt.src, args, last, tt.args, tt.last)
+		}
+	}
+}
+
+// Перенос решения на другой язык и несвязанные программы для калибровки
+// порога CrossThreshold
+const irMaxPy = `def find_max(a):
+    best = a[0]
+    for i in range(len(a)):
+        if a[i] > best:
+            best = a[i]
+    return best
+
+
+def count_positive(a):
+    count = 0
+    for i in range(len(a)):
+        if a[i] > 0:
+            count += 1
+    return count
+`
+
+const irMaxJava = `public class Solution {
+    public static int findMax(int[] a) {
+        int best = a[0];
+        for (int i = 0; i < a.length; i++) {
+            if (a[i] > best) {
+                best = a[i];
+            }
+        }
+        return best;
+    }
+
+    public static int countPositive(int[] a) {
+        int count = 0;
+        for (int i = 0; i < a.length; i++) {
+            if (a[i] > 0) {
+                count++;
+            }
+        }
+        return count;
+    }
+}
+`
+
+const irSortPy = `def bubble_sort(items):
+    n = len(items)
+    for i in range(n):
+        swapped = False
+        for j in range(0, n - i - 1):
+            if items[j] > items[j + 1]:
+                items[j], items[j + 1] = items[j + 1], items[j]
+                swapped = True
+        if not swapped:
+            break
+    return items
+
+
+def binary_search(items, target):
+    lo, hi = 0, len(items) - 1
+    while lo <= hi:
+        mid = (lo + hi) // 2
+        if items[mid] == target:
+            return mid
+        elif items[mid] < target:
+            lo = mid + 1
+        else:
+            hi = mid - 1
+    return -1
+`
+
+const irSortGo = `package main
+
+func bubbleSort(items []int) []int {
+	n := len(items)
+	for i := 0; i < n; i++ {
+		swapped := false
+		for j := 0; j < n-i-1; j++ {
+			if items[j] > items[j+1] {
+				items[j], items[j+1] = items[j+1], items[j]
+				swapped = true
+			}
+		}
+		if !swapped {
+			break
+		}
+	}
+	return items
+}
+
+func binarySearch(items []int, target int) int {
+	lo, hi := 0, len(items)-1
+	for lo <= hi {
+		mid := (lo + hi) / 2
+		if items[mid] == target {
+			return mid
+		} else if items[mid] < target {
+			lo = mid + 1
+		} else {
+			hi = mid - 1
+		}
+	}
+	return -1
+}
+`
+
+const irSortJs = `function bubbleSort(items) {
+  const n = items.length;
+  for (let i = 0; i < n; i++) {
+    let swapped = false;
+    for (let j = 0; j < n - i - 1; j++) {
+      if (items[j] > items[j + 1]) {
+        const tmp = items[j];
+        items[j] = items[j + 1];
+        items[j + 1] = tmp;
+        swapped = true;
+      }
+    }
+    if (!swapped) {
+      break;
+    }
+  }
+  return items;
+}
+
+function binarySearch(items, target) {
+  let lo = 0;
+  let hi = items.length - 1;
+  while (lo <= hi) {
+    const mid = Math.floor((lo + hi) / 2);
+    if (items[mid] === target) {
+      return mid;
+    } else if (items[mid] < target) {
+      lo = mid + 1;
+    } else {
+      hi = mid - 1;
+    }
+  }
+  return -1;
+}
+`
+
+const irBankJava = `import java.util.ArrayList;
+import java.util.List;
+
+public class Bank {
+    private final List<Account> accounts = new ArrayList<>();
+
+    public Account open(String owner, double deposit) {
+        Account acc = new Account(owner, deposit);
+        accounts.add(acc);
+        return acc;
+    }
+
+    public void transfer(Account from, Account to, double amount) {
+        if (amount <= 0) {
+            throw new IllegalArgumentException("amount");
+        }
+        if (from.getBalance() < amount) {
+            throw new IllegalStateException("funds");
+        }
+        from.withdraw(amount);
+        to.deposit(amount);
+    }
+
+    public double total() {
+        double sum = 0;
+        for (Account a : accounts) {
+            sum += a.getBalance();
+        }
+        return sum;
+    }
+}
+`
+
+const irGradesPy = `import csv
+import statistics
+
+
+class Gradebook:
+    def __init__(self):
+        self.grades = {}
+
+    def load(self, path):
+        with open(path) as f:
+            for row in csv.reader(f):
+                name, score = row[0], float(row[1])
+                self.grades.setdefault(name, []).append(score)
+
+    def report(self):
+        lines = []
+        for name, scores in sorted(self.grades.items()):
+            avg = statistics.mean(scores)
+            grade = "A" if avg >= 90 else "B" if avg >= 75 else "C"
+            lines.append(f"{name}: {avg:.1f} {grade}")
+        return "\n".join(lines)
+`
+
+const irMatrixC = `#include <stdio.h>
+#include <stdlib.h>
+
+int **alloc_matrix(int rows, int cols) {
+    int **m = malloc(rows * sizeof(int *));
+    for (int i = 0; i < rows; i++) {
+        m[i] = calloc(cols, sizeof(int));
+    }
+    return m;
+}
+
+void multiply(int **a, int **b, int **c, int n) {
+    for (int i = 0; i < n; i++)
+        for (int j = 0; j < n; j++) {
+            c[i][j] = 0;
+            for (int k = 0; k < n; k++)
+                c[i][j] += a[i][k] * b[k][j];
+        }
+}
+
+int main(void) {
+    int n;
+    scanf("%d", &n);
+    int **a = alloc_matrix(n, n);
+    printf("%d\n", a[0][0]);
+    return 0;
+}
+`
+
+func TestCompareIRCalibration(t *testing.T) {
+	tests := []struct {
+		name        string
+		lang1, src1 string
+		lang2, src2 string
+		port        bool
+	}{
+		{"python->java", "python", irMaxPy, "java", irMaxJava, true},
+		{"python->go", "python", irSortPy, "golang", irSortGo, true},
+		{"python->javascript", "python", irSortPy, "javascript", irSortJs, true},
+		{"похожая тема", "python", irMaxPy, "javascript", irSortJs, false},
+		{"несвязанные java/python", "java", irBankJava, "python", irGradesPy, false},
+		{"несвязанные c/python", "c", irMatrixC, "python", irSortPy, false},
+		{"несвязанные java/go", "java", irMaxJava, "golang", irSortGo, false},
+	}
+	threshold := defaultScoringConfig().CrossThreshold
+	for _, tt := range tests {
+		ir1 := buildIR(tokenize(tt.src1, tt.lang1), tt.lang1)
+		ir2 := buildIR(tokenize(tt.src2, tt.lang2), tt.lang2)
+		similarity, _, _ := compareIR(ir1, ir2)
+		if tt.port && similarity < threshold {
+			t.Errorf("%s: перенос получил %.1f%%, порог %g%%", tt.name, similarity, threshold)
+		}
+		if !tt.port && similarity >= threshold/2 {
+			t.Errorf("%s: несвязанные работы получили %.1f%%, порог %g%%", tt.name, similarity, threshold)
+		}
+	}
+}
+
+func TestCompareIRSymmetric(t *testing.T) {
+	ir1 := buildIR(tokenize(irMaxPy, "python"), "python")
+	ir2 := buildIR(tokenize(irMaxJava, "java"), "java")
+	s1, c1, c2 := compareIR(ir1, ir2)
+	s2, d1, d2 := compareIR(ir2, ir1)
+	if s1 != s2 || !reflect.DeepEqual([]float64{c1, c2}, []float64{d2, d1}) {
+		t.Errorf("сравнение несимметрично: %.1f/%.1f/%.1f и %.1f/%.1f/%.1f", s1, c1, c2, s2, d1, d2)
+	}
+}
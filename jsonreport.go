@@ -0,0 +1,307 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+// Версия формата JSON-отчета. Увеличивается при несовместимых изменениях схемы;
+// новые поля добавляются без смены версии.
+const jsonReportVersion = 1
+
+// jsonReport JSON-отчет для других программ (журналы оценок, панели).
+// Схема описана отдельными структурами, чтобы внутренние изменения не меняли формат.
+type jsonReport struct {
+	Version     int               `json:"version"`
+	GeneratedAt time.Time         `json:"generated_at"`
+	Config      jsonReportConfig  `json:"config"`
+	Summary     jsonReportSummary `json:"summary"`
+	Projects    []jsonProject     `json:"projects"`
+	Results     []jsonResult      `json:"results"` // все сравненные пары, отмеченные flagged при оценке не ниже порога
+	Matrix      jsonMatrix        `json:"matrix"`
+	Clusters    []jsonCluster     `json:"clusters"` // группы из трех и более работ, связанных парами выше порога
+}
+
+// jsonCluster группа работ, связанных подозрительными парами
+type jsonCluster struct {
+	Members      []string `json:"members"`
+	AverageScore float64  `json:"average_score"` // средняя оценка по всем парам участников
+	LikelySource string   `json:"likely_source"`
+	SourceReason string   `json:"source_reason"`
+	Pairs        int      `json:"pairs"` // пар выше порога внутри группы
+}
+
+// jsonMatrix полная матрица оценок текущей когорты
+type jsonMatrix struct {
+	Projects     []string     `json:"projects"`
+	Scores       [][]*float64 `json:"scores"`        // null - работы на разных языках
+	ClusterOrder []string     `json:"cluster_order"` // порядок работ после кластеризации
+}
+
+// jsonReportConfig настройки, с которыми получены результаты
+type jsonReportConfig struct {
+	AnalyzerVersion string             `json:"analyzer_version"`
+	Input           string             `json:"input"`
+	Course          string             `json:"course,omitempty"`
+	Assignment      string             `json:"assignment,omitempty"`
+	Year            int                `json:"year"`
+	Languages       []string           `json:"languages,omitempty"`
+	Algorithm       string             `json:"algorithm"`
+	K               int                `json:"k"`
+	Window          int                `json:"window"`
+	MinMatch        int                `json:"min_match"`
+	Base            string             `json:"base,omitempty"`
+	Common          float64            `json:"common"`
+	MinShared       int                `json:"min_shared"`
+	Weights         map[string]float64 `json:"weights"`
+	Threshold       float64            `json:"threshold"`
+	High            float64            `json:"high"`
+	Medium          float64            `json:"medium"`
+	Clusters        string             `json:"clusters"`
+	CrossLanguage   bool               `json:"cross_language"`
+	CrossThreshold  float64            `json:"cross_threshold"`
+}
+
+// jsonReportSummary сводные числа запуска
+type jsonReportSummary struct {
+	Projects   int `json:"projects"`
+	TotalPairs int `json:"total_pairs"`
+	Compared   int `json:"compared"`
+	Pruned     int `json:"pruned"`
+	CrossPairs int `json:"cross_pairs"`
+	Flagged    int `json:"flagged"`
+	High       int `json:"high"`
+	Medium     int `json:"medium"`
+	Low        int `json:"low"`
+}
+
+// jsonProject работа студента
+type jsonProject struct {
+	Name          string   `json:"name"`
+	Dir           string   `json:"dir"`
+	Language      string   `json:"language"`
+	Files         []string `json:"files"`
+	BaseRemoved   float64  `json:"base_removed"`
+	CommonRemoved float64  `json:"common_removed"`
+}
+
+// jsonResult результат сравнения пары
+type jsonResult struct {
+	Project1            string              `json:"project1"`
+	Project2            string              `json:"project2"`
+	Language            string              `json:"language"` // у пар на разных языках - "язык1/язык2"
+	CrossLanguage       bool                `json:"cross_language"`
+	IRSimilarity        *float64            `json:"ir_similarity,omitempty"` // схожесть промежуточного представления
+	OverallScore        float64             `json:"overall_score"`
+	Band                string              `json:"band"` // high, medium или low
+	Flagged             bool                `json:"flagged"`
+	ArchiveYear         int                 `json:"archive_year,omitempty"`
+	Metrics             map[string]*float64 `json:"metrics"` // null - метрику нельзя вычислить для пары
+	TileCoverage        *float64            `json:"tile_coverage,omitempty"`
+	Containment1        *float64            `json:"containment1"` // доля кода project1, найденная в project2; null без метрики кода
+	Containment2        *float64            `json:"containment2"` // доля кода project2, найденная в project1
+	MatchedFingerprints int                 `json:"matched_fingerprints"`
+	Files1              []string            `json:"files1"`
+	Files2              []string            `json:"files2"`
+	FileMatrix          [][]*float64        `json:"file_matrix"` // null - файлы на разных языках
+	Fragments           []jsonFragment      `json:"fragments"`
+	Clones              []jsonClone         `json:"clones"`
+}
+
+// jsonPosition позиция в исходном файле
+type jsonPosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// jsonFragment совпавший участок кода
+type jsonFragment struct {
+	File1  string       `json:"file1"`
+	Start1 jsonPosition `json:"start1"`
+	End1   jsonPosition `json:"end1"`
+	File2  string       `json:"file2"`
+	Start2 jsonPosition `json:"start2"`
+	End2   jsonPosition `json:"end2"`
+	Tokens int          `json:"tokens"`
+}
+
+// jsonClone пара структурно похожих функций
+type jsonClone struct {
+	Function1  string  `json:"function1"`
+	Function2  string  `json:"function2"`
+	Similarity float64 `json:"similarity"`
+	Exact      bool    `json:"exact"`
+	Location1  string  `json:"location1,omitempty"`
+	Location2  string  `json:"location2,omitempty"`
+}
+
+// buildJSONReport переводит результаты запуска в схему JSON-отчета
+func buildJSONReport(generatedAt time.Time, projects []ProjectSummary, results []ComparisonResult, stats ComparisonStats, matrix SimilarityMatrix, clusters []PlagiarismCluster) jsonReport {
+	scoring := config.Scoring
+	report := jsonReport{
+		Version:     jsonReportVersion,
+		GeneratedAt: generatedAt,
+		Config: jsonReportConfig{
+			AnalyzerVersion: analyzerVersion,
+			Input:           config.InputDir,
+			Course:          config.Course,
+			Assignment:      config.Assignment,
+			Year:            config.Year,
+			Languages:       config.Languages,
+			Algorithm:       config.Algorithm,
+			K:               config.Fingerprint.K,
+			Window:          config.Fingerprint.Window,
+			MinMatch:        config.MinMatchLength,
+			Base:            config.BaseDir,
+			Common:          config.CommonFraction,
+			MinShared:       config.MinShared,
+			Weights:         scoring.Weights,
+			Threshold:       scoring.Threshold,
+			High:            scoring.HighBand,
+			Medium:          scoring.MediumBand,
+			Clusters:        config.ClusterMethod,
+			CrossLanguage:   config.CrossLanguage,
+			CrossThreshold:  scoring.CrossThreshold,
+		},
+		Summary: jsonReportSummary{
+			Projects:   len(projects),
+			TotalPairs: stats.TotalPairs,
+			Compared:   stats.Compared,
+			Pruned:     stats.Pruned,
+			CrossPairs: stats.CrossPairs,
+		},
+		Projects: make([]jsonProject, 0, len(projects)),
+		Results:  make([]jsonResult, 0, len(results)),
+		Clusters: make([]jsonCluster, 0, len(clusters)),
+	}
+
+	for _, p := range projects {
+		report.Projects = append(report.Projects, jsonProject{
+			Name:          p.Name,
+			Dir:           p.Dir,
+			Language:      p.Language,
+			Files:         p.Files,
+			BaseRemoved:   p.BaseRemoved,
+			CommonRemoved: p.CommonRemoved,
+		})
+	}
+
+	for _, r := range results {
+		result := jsonResult{
+			Project1:            r.Project1,
+			Project2:            r.Project2,
+			Language:            r.Language,
+			OverallScore:        r.OverallScore,
+			Band:                scoring.band(r.OverallScore),
+			Flagged:             scoring.flagged(r),
+			CrossLanguage:       r.CrossLanguage,
+			ArchiveYear:         r.ArchiveYear,
+			Metrics:             make(map[string]*float64),
+			MatchedFingerprints: len(r.Matches),
+			Files1:              r.Files1,
+			Files2:              r.Files2,
+			Fragments:           []jsonFragment{},
+			Clones:              []jsonClone{},
+		}
+		for _, metric := range allMetrics {
+			if r.IsAvailable(metric) {
+				value := r.MetricValue(metric)
+				result.Metrics[metric] = &value
+			} else {
+				result.Metrics[metric] = nil
+			}
+		}
+		if config.usesGST() {
+			coverage := r.TileCoverage
+			result.TileCoverage = &coverage
+		}
+		if r.CrossLanguage {
+			similarity := r.IRSimilarity
+			result.IRSimilarity = &similarity
+		}
+		if r.CrossLanguage || r.IsAvailable(metricText) {
+			containment1, containment2 := r.Containment1, r.Containment2
+			result.Containment1, result.Containment2 = &containment1, &containment2
+		}
+		for _, row := range r.FileMatrix {
+			result.FileMatrix = append(result.FileMatrix, nullableScores(row))
+		}
+		for _, f := range r.Fragments {
+			result.Fragments = append(result.Fragments, jsonFragment{
+				File1:  fileName(r.Files1, f.File1),
+				Start1: jsonPosition{f.Start1.Line, f.Start1.Column},
+				End1:   jsonPosition{f.End1.Line, f.End1.Column},
+				File2:  fileName(r.Files2, f.File2),
+				Start2: jsonPosition{f.Start2.Line, f.Start2.Column},
+				End2:   jsonPosition{f.End2.Line, f.End2.Column},
+				Tokens: f.Tokens,
+			})
+		}
+		for _, c := range r.Clones {
+			result.Clones = append(result.Clones, jsonClone{
+				Function1:  c.Function1,
+				Function2:  c.Function2,
+				Similarity: c.Similarity,
+				Exact:      c.Exact,
+				Location1:  c.Location1.String(),
+				Location2:  c.Location2.String(),
+			})
+		}
+
+		if result.Flagged {
+			report.Summary.Flagged++
+			switch result.Band {
+			case bandHigh:
+				report.Summary.High++
+			case bandMedium:
+				report.Summary.Medium++
+			default:
+				report.Summary.Low++
+			}
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	report.Matrix.Projects = matrix.Projects
+	for _, row := range matrix.Scores {
+		report.Matrix.Scores = append(report.Matrix.Scores, nullableScores(row))
+	}
+	for _, i := range matrix.clusterOrder() {
+		report.Matrix.ClusterOrder = append(report.Matrix.ClusterOrder, matrix.Projects[i])
+	}
+	for _, c := range clusters {
+		report.Clusters = append(report.Clusters, jsonCluster{
+			Members:      c.Members,
+			AverageScore: c.AverageScore,
+			LikelySource: c.LikelySource,
+			SourceReason: c.SourceReason,
+			Pairs:        len(c.Edges),
+		})
+	}
+	return report
+}
+
+// nullableScores заменяет отрицательные значения (нет сравнения) на null
+func nullableScores(row []float64) []*float64 {
+	cells := make([]*float64, len(row))
+	for j := range row {
+		if row[j] >= 0 {
+			cells[j] = &row[j]
+		}
+	}
+	return cells
+}
+
+// writeJSONReport сохраняет JSON-отчет
+func writeJSONReport(path string, report jsonReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("сериализация JSON-отчета: %w", err)
+	}
+	if err := ioutil.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("запись %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// goldenReport отчет, в котором заполнено каждое поле схемы
+func goldenReport() jsonReport {
+	score := func(v float64) *float64 { return &v }
+	pos := func(line, column int) jsonPosition { return jsonPosition{Line: line, Column: column} }
+	return jsonReport{
+		Version:     jsonReportVersion,
+		GeneratedAt: time.Date(2024, 5, 20, 14, 30, 0, 0, time.UTC),
+		Config: jsonReportConfig{
+			AnalyzerVersion: "1",
+			Input:           "works",
+			Course:          "algo",
+			Assignment:      "lab1",
+			Year:            2024,
+			Languages:       []string{"python"},
+			Algorithm:       algorithmBoth,
+			K:               7,
+			Window:          5,
+			MinMatch:        9,
+			Base:            "template",
+			Common:          0.5,
+			MinShared:       1,
+			Weights:         map[string]float64{"text": 2, "tokens": 1},
+			Threshold:       60,
+			High:            80,
+			Medium:          60,
+			Clusters:        clusterComponents,
+			CrossLanguage:   true,
+			CrossThreshold:  50,
+		},
+		Matrix: jsonMatrix{
+			Projects:     []string{"alice", "bob"},
+			Scores:       [][]*float64{{nil, score(85.5)}, {score(85.5), nil}},
+			ClusterOrder: []string{"bob", "alice"},
+		},
+		Summary: jsonReportSummary{Projects: 2, TotalPairs: 1, Compared: 1, Flagged: 1, High: 1, CrossPairs: 1},
+		Projects: []jsonProject{
+			{Name: "alice", Dir: "works/alice", Language: "python", Files: []string{"main.py"}, BaseRemoved: 12.5},
+			{Name: "bob", Dir: "works/bob", Language: "python", Files: []string{"main.py"}, CommonRemoved: 3},
+		},
+		Clusters: []jsonCluster{{
+			Members:      []string{"alice", "bob", "carol"},
+			AverageScore: 82,
+			LikelySource: "alice",
+			SourceReason: "самая ранняя сдача",
+			Pairs:        3,
+		}},
+		Results: []jsonResult{{
+			Project1:            "alice",
+			Project2:            "bob",
+			Language:            "python",
+			OverallScore:        85.5,
+			Band:                bandHigh,
+			Flagged:             true,
+			CrossLanguage:       true,
+			ArchiveYear:         2023,
+			Metrics:             map[string]*float64{"text": score(90), "ast": nil},
+			TileCoverage:        score(70),
+			IRSimilarity:        score(65),
+			Containment1:        score(75),
+			Containment2:        score(60),
+			MatchedFingerprints: 4,
+			Files1:              []string{"main.py"},
+			Files2:              []string{"main.py"},
+			FileMatrix:          [][]*float64{{score(88), nil}},
+			Fragments: []jsonFragment{{
+				File1: "main.py", Start1: pos(1, 1), End1: pos(3, 12),
+				File2: "main.py", Start2: pos(2, 5), End2: pos(4, 1), Tokens: 20,
+			}},
+			Clones: []jsonClone{{
+				Function1: "solve", Function2: "answer", Similarity: 95, Exact: true,
+				Location1: "main.py:1", Location2: "main.py:2",
+			}},
+		}},
+	}
+}
+
+// goldenJSON эталонная сериализация goldenReport: поля и их имена - публичный формат
+const goldenJSON = `{
+  "version": 1,
+  "generated_at": "2024-05-20T14:30:00Z",
+  "config": {
+    "analyzer_version": "1",
+    "input": "works",
+    "course": "algo",
+    "assignment": "lab1",
+    "year": 2024,
+    "languages": [
+      "python"
+    ],
+    "algorithm": "both",
+    "k": 7,
+    "window": 5,
+    "min_match": 9,
+    "base": "template",
+    "common": 0.5,
+    "min_shared": 1,
+    "weights": {
+      "text": 2,
+      "tokens": 1
+    },
+    "threshold": 60,
+    "high": 80,
+    "medium": 60,
+    "clusters": "components",
+    "cross_language": true,
+    "cross_threshold": 50
+  },
+  "summary": {
+    "projects": 2,
+    "total_pairs": 1,
+    "compared": 1,
+    "pruned": 0,
+    "cross_pairs": 1,
+    "flagged": 1,
+    "high": 1,
+    "medium": 0,
+    "low": 0
+  },
+  "projects": [
+    {
+      "name": "alice",
+      "dir": "works/alice",
+      "language": "python",
+      "files": [
+        "main.py"
+      ],
+      "base_removed": 12.5,
+      "common_removed": 0
+    },
+    {
+      "name": "bob",
+      "dir": "works/bob",
+      "language": "python",
+      "files": [
+        "main.py"
+      ],
+      "base_removed": 0,
+      "common_removed": 3
+    }
+  ],
+  "results": [
+    {
+      "project1": "alice",
+      "project2": "bob",
+      "language": "python",
+      "cross_language": true,
+      "ir_similarity": 65,
+      "overall_score": 85.5,
+      "band": "high",
+      "flagged": true,
+      "archive_year": 2023,
+      "metrics": {
+        "ast": null,
+        "text": 90
+      },
+      "tile_coverage": 70,
+      "containment1": 75,
+      "containment2": 60,
+      "matched_fingerprints": 4,
+      "files1": [
+        "main.py"
+      ],
+      "files2": [
+        "main.py"
+      ],
+      "file_matrix": [
+        [
+          88,
+          null
+        ]
+      ],
+      "fragments": [
+        {
+          "file1": "main.py",
+          "start1": {
+            "line": 1,
+            "column": 1
+          },
+          "end1": {
+            "line": 3,
+            "column": 12
+          },
+          "file2": "main.py",
+          "start2": {
+            "line": 2,
+            "column": 5
+          },
+          "end2": {
+            "line": 4,
+            "column": 1
+          },
+          "tokens": 20
+        }
+      ],
+      "clones": [
+        {
+          "function1": "solve",
+          "function2": "answer",
+          "similarity": 95,
+          "exact": true,
+          "location1": "main.py:1",
+          "location2": "main.py:2"
+        }
+      ]
+    }
+  ],
+  "matrix": {
+    "projects": [
+      "alice",
+      "bob"
+    ],
+    "scores": [
+      [
+        null,
+        85.5
+      ],
+      [
+        85.5,
+        null
+      ]
+    ],
+    "cluster_order": [
+      "bob",
+      "alice"
+    ]
+  },
+  "clusters": [
+    {
+      "members": [
+        "alice",
+        "bob",
+        "carol"
+      ],
+      "average_score": 82,
+      "likely_source": "alice",
+      "source_reason": "самая ранняя сдача",
+      "pairs": 3
+    }
+  ]
+}`
+
+func TestJSONReportSchema(t *testing.T) {
+	data, err := json.MarshalIndent(goldenReport(), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != goldenJSON {
+		t.Errorf("схема JSON-отчета изменилась:\n%s", data)
+	}
+
+	var parsed jsonReport
+	if err := json.Unmarshal([]byte(goldenJSON), &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, goldenReport()) {
+		t.Errorf("отчет после разбора:\n%+v\nожидалось:\n%+v", parsed, goldenReport())
+	}
+}
@@ -11,19 +11,35 @@ import (
 	"time"
 )
 
-// Project структура
+// Project работа одного студента: корневая папка со всеми его файлами.
+// Встроенный SourceFile содержит сводный анализ всех файлов работы.
 type Project struct {
-	Name        string
-	Content     string
-	Comments    string
-	Identifiers Identifiers
-	ControlFlow ControlFlow
-	Functions   FunctionAnalysis
-	Imports     ImportAnalysis
-	Formatting  FormatAnalysis
-	Tokens      TokenAnalysis
-	FilePath    string
-	Language    string // Добавляем определение языка программирования
+	Name  string
+	Dir   string
+	Files []SourceFile // анализ каждого файла по отдельности
+	SourceFile
+
+	BaseRemoved   float64 // доля отпечатков, совпавших с шаблоном задания
+	CommonRemoved float64 // доля отпечатков, общих для большей части когорты
+	ArchiveYear   int     // год работы из архива, 0 для работ текущего запуска
+}
+
+// SourceFile результат анализа одного исходного файла
+type SourceFile struct {
+	Comments     string
+	Identifiers  Identifiers
+	ControlFlow  ControlFlow
+	Functions    FunctionAnalysis
+	Imports      ImportAnalysis
+	Formatting   FormatAnalysis
+	Tokens       TokenAnalysis
+	Fingerprints []Fingerprint         // отпечатки winnowing нормализованного потока токенов
+	ASTShape     []string              // структурный отпечаток AST (для языков с разбором AST)
+	Bodies       []FunctionFingerprint // отпечатки тел функций для поиска клонов
+	FilePath     string
+	Language     string          // Добавляем определение языка программирования
+	Available    map[string]bool // метрики, которые удалось вычислить
+	TokenOffset  int             // позиция первого токена файла в сводном потоке работы
 }
 
 // ComparisonResult хранит результат сравнения двух проектов
@@ -38,7 +54,64 @@ type ComparisonResult struct {
 	ImportSimilarity      float64
 	FormatSimilarity      float64
 	TokenSimilarity       float64
+	ASTSimilarity         float64
 	Language              string
+	Unavailable           []string           // метрики, которые нельзя вычислить для этой пары
+	Files1                []string           // файлы первой работы (относительно ее папки)
+	Files2                []string           // файлы второй работы
+	FileMatrix            [][]float64        // схожесть файлов Files1[i] и Files2[j], -1 если языки разные
+	Matches               []FingerprintMatch // совпавшие отпечатки для показа фрагментов
+	Tiles                 []Tile             // совпавшие отрезки Greedy String Tiling
+	TileCoverage          float64            // покрытие токенов отрезками GST
+	OverallScore          float64            // взвешенная общая оценка по настройкам Scoring
+	Clones                []FunctionClone    // структурно похожие функции независимо от имен
+	Paths1                []string           // пути файлов первой работы на диске
+	Paths2                []string           // пути файлов второй работы на диске
+	Fragments             []MatchedFragment  // совпавшие участки исходников для страницы пары
+	ArchiveYear           int                // год архивной работы в паре, 0 если обе работы текущие
+	Containment1          float64            // доля кода первой работы, найденная во второй
+	Containment2          float64            // доля кода второй работы, найденная в первой
+	CrossLanguage         bool               // работы на разных языках, сравнивалось промежуточное представление
+	IRSimilarity          float64            // схожесть промежуточных представлений пары на разных языках
+}
+
+// Названия метрик сравнения
+const (
+	metricText        = "text"
+	metricComments    = "comments"
+	metricIdentifiers = "identifiers"
+	metricControlFlow = "controlflow"
+	metricFunctions   = "functions"
+	metricImports     = "imports"
+	metricFormatting  = "formatting"
+	metricTokens      = "tokens"
+	metricAST         = "ast"
+)
+
+// allMetrics задает порядок метрик в отчетах
+var allMetrics = []string{
+	metricText,
+	metricComments,
+	metricIdentifiers,
+	metricControlFlow,
+	metricFunctions,
+	metricImports,
+	metricFormatting,
+	metricTokens,
+	metricAST,
+}
+
+// metricLabels задает названия метрик для отчетов
+var metricLabels = map[string]string{
+	metricText:        "Код (отпечатки)",
+	metricComments:    "Комментарии",
+	metricIdentifiers: "Идентификаторы",
+	metricControlFlow: "Поток управления",
+	metricFunctions:   "Функции",
+	metricImports:     "Импорты",
+	metricFormatting:  "Форматирование",
+	metricTokens:      "Токены",
+	metricAST:         "Структура AST",
 }
 
 // Добавляем структуру для хранения идентификаторов
@@ -66,6 +139,7 @@ type FunctionAnalysis struct {
 	ReturnTypes   map[string]string // типы возвращаемых значений
 	FunctionSizes map[string]int    // размеры функций
 	DeclareOrder  []string          // порядок объявления функций
+	ParamTypes    map[string]string // списки параметров с типами (если известны)
 }
 
 // Добавляем структуру для анализа импортов
@@ -86,6 +160,8 @@ type FormatAnalysis struct {
 type TokenAnalysis struct {
 	OperatorSequence string
 	TokenPatterns    []string
+	TokenPositions   []SourcePos // позиция каждого токена из TokenPatterns в исходном файле
+	IR               []string    // языконезависимое представление для сравнения работ на разных языках
 }
 
 // Добавим список поддерживаемых расширений файлов
@@ -113,23 +189,31 @@ type HtmlReport struct {
 	HighSimilarityCount   int
 	MediumSimilarityCount int
 	LowSimilarityCount    int
+	ShowTiles             bool // показывать покрытие GST
+	Projects              []ProjectSummary
+	ShowBaseCode          bool // показывать долю исключенного шаблона
+	ShowCommonCode        bool // показывать долю подавленного общего кода
+	Scoring               ScoringConfig
+	Stats                 ComparisonStats
+	ArchiveHits           int    // пар с работами из архива
+	PairsDir              string // папка страниц пар относительно отчета
+	Heatmaps              []heatmapView
+	Clusters              []clusterView
+	ClusterMethod         string // способ поиска групп
 }
 
 func main() {
-	projectsDir := "./projects" // директория с проектами студентов
-	projects, err := loadProjects(projectsDir)
-	if err != nil {
-		fmt.Printf("О��ибка при загрузке проектов: %v\n", err)
-		return
-	}
-
-	results := compareAllProjects(projects)
-	printResults(results)
+	os.Exit(runCLI(os.Args[1:]))
 }
 
-// loadProjects загружает все проекты из указанной директории
+// loadProjects загружает все проекты из указанной директории.
+// Каждая папка верхнего уровня - это работа одного студента со всеми вложенными файлами;
+// файл, лежащий прямо в dir, считается отдельной работой.
 func loadProjects(dir string) ([]Project, error) {
 	var projects []Project
+	var order []string
+	files := make(map[string][]SourceFile)
+	dirs := make(map[string]string)
 	fmt.Println("Начинаю загрузку проектов...")
 	fmt.Printf("Поиск проектов в директории: %s\n", dir)
 
@@ -138,9 +222,15 @@ func loadProjects(dir string) ([]Project, error) {
 			return err
 		}
 
+		if rel, err := filepath.Rel(dir, path); err == nil && config.ignored(rel) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
 		if !info.IsDir() {
-			ext := strings.ToLower(filepath.Ext(path))
-			if lang, ok := supportedExtensions[ext]; ok {
+			if lang, ok := config.fileLanguage(path); ok {
 				fmt.Printf("Обнаружен файл: %s (язык: %s)\n", path, lang)
 				content, err := ioutil.ReadFile(path)
 				if err != nil {
@@ -148,68 +238,108 @@ func loadProjects(dir string) ([]Project, error) {
 				}
 
 				rawContent := string(content)
-				projectName := filepath.Base(filepath.Dir(path))
-				fmt.Printf("Анализирую проект: %s\n", projectName)
-
-				project := Project{
-					Name:        projectName,
-					Content:     normalizeCode(rawContent, lang),
-					Comments:    extractComments(rawContent, lang),
-					Identifiers: extractIdentifiers(rawContent, lang),
-					FilePath:    path,
-					Language:    lang,
+				projectName, projectDir := submissionOf(dir, path)
+
+				if _, seen := files[projectName]; !seen {
+					order = append(order, projectName)
+					dirs[projectName] = projectDir
+				}
+				file, cached := analyzeFileCached(path, rawContent, lang)
+				if cached {
+					fmt.Printf("Анализ файла проекта %s взят из кэша\n", projectName)
+				} else {
+					fmt.Printf("Проанализирован файл проекта: %s\n", projectName)
 				}
-				projects = append(projects, project)
-				fmt.Printf("Проект %s успешно загружен и проанализирован\n", projectName)
-				fmt.Println("----------------------------------------")
+				files[projectName] = append(files[projectName], file)
 			}
 		}
 		return nil
 	})
 
+	for _, name := range order {
+		project := buildProject(name, dirs[name], files[name])
+		projects = append(projects, project)
+		fmt.Printf("Проект %s (%d файлов) успешно загружен и проанализирован\n", name, len(project.Files))
+	}
+	fmt.Println("----------------------------------------")
+
 	fmt.Printf("Загружено проектов: %d\n\n", len(projects))
 	return projects, err
 }
 
-// normalizeCode подготавливает код для сравнения
-func normalizeCode(code, language string) string {
-	// Удаляем комментарии в зависимости от языка
-	switch language {
-	case "python":
-		// Удаляем Python-комментарии
-		lines := strings.Split(code, "\n")
-		var filtered []string
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if !strings.HasPrefix(line, "#") && line != "" {
-				filtered = append(filtered, line)
-			}
+// analyzeFile прогоняет исходный код файла через все анализаторы
+func analyzeFile(path, rawContent, lang string) SourceFile {
+	// Код без комментариев нужен для импортов, без строк - для остальных анализаторов
+	withoutComments := removeComments(rawContent, lang)
+	cleanCode := removeStringLiterals(withoutComments)
+	tokens := tokenize(rawContent, lang)
+
+	file := SourceFile{
+		Comments:    extractComments(rawContent, lang),
+		Identifiers: extractIdentifiers(rawContent, lang),
+		ControlFlow: analyzeControlFlow(cleanCode, lang),
+		Functions:   analyzeFunctions(cleanCode, lang),
+		Imports:     analyzeImports(withoutComments, lang),
+		Formatting:  analyzeFormatting(rawContent),
+		Tokens:      analyzeTokens(tokens, lang),
+		FilePath:    path,
+		Language:    lang,
+	}
+	// Для Go используем настоящий AST вместо регулярных выражений
+	if lang == "golang" {
+		if ga, err := analyzeGoSource(path, rawContent); err == nil {
+			file.Identifiers = ga.Identifiers
+			file.Functions = ga.Functions
+			file.Imports = ga.Imports
+			file.ControlFlow = ga.ControlFlow
+			file.ASTShape = ga.ASTShape
+			file.Bodies = ga.Bodies
+		} else {
+			fmt.Printf("Не удалось разобрать Go-код, используются регулярные выражения: %v\n", err)
 		}
-		code = strings.Join(filtered, " ")
-	default:
-		// Для остальных языков удаляем стандартные комментарии
-		lines := strings.Split(code, "\n")
-		var filtered []string
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if !strings.HasPrefix(line, "//") && !strings.HasPrefix(line, "/*") &&
-				!strings.HasSuffix(line, "*/") && line != "" {
-				filtered = append(filtered, line)
-			}
+	} else if fa, ok := analyzeSourceTokens(tokens, lang); ok {
+		// Для остальных языков - разбор по лексемам
+		file.Identifiers = fa.Identifiers
+		file.Functions = fa.Functions
+		file.Imports = fa.Imports
+		file.ControlFlow = fa.ControlFlow
+		file.Bodies = fa.Bodies
+		for i := range file.Bodies {
+			file.Bodies[i].Location.File = path
 		}
-		code = strings.Join(filtered, " ")
 	}
 
-	// Общая нормализация
-	code = strings.ToLower(code)
+	file.Fingerprints = fingerprintTokens(file.Tokens.TokenPatterns, config.Fingerprint)
+	file.Available = detectAvailableMetrics(file)
 
-	// Удаляем строковые литералы
-	code = removeStringLiterals(code)
+	return file
+}
 
-	// Удаляем лишние пробелы
-	code = strings.Join(strings.Fields(code), " ")
+// detectAvailableMetrics определяет, какие метрики имеют смысл для файла или работы.
+// Метрика недоступна, если анализатор не поддерживает язык или ничего не нашел,
+// иначе пустые значения у двух проектов дают ложное совпадение.
+func detectAvailableMetrics(p SourceFile) map[string]bool {
+	available := map[string]bool{
+		metricText:       len(p.Fingerprints) > 0,
+		metricComments:   p.Comments != "",
+		metricFormatting: len(p.Tokens.TokenPatterns) > 0,
+	}
 
-	return code
+	ids := p.Identifiers
+	available[metricIdentifiers] = len(ids.Variables)+len(ids.Functions)+len(ids.Classes)+
+		len(ids.Interfaces)+len(ids.Constants) > 0
+
+	_, frontend := sourceFrontends[p.Language]
+	available[metricControlFlow] = p.Language == "golang" || frontend
+
+	available[metricFunctions] = len(p.Functions.DeclareOrder) > 0 || len(p.Bodies) > 0
+	available[metricImports] = len(p.Imports.ImportList) > 0
+
+	// Для n-граммного сравнения нужна хотя бы одна полная n-грамма
+	available[metricTokens] = len(p.Tokens.TokenPatterns) >= tokenNGramSize
+	available[metricAST] = len(p.ASTShape) >= astNGramSize
+
+	return available
 }
 
 // Добавляем функцию для удаления строковых литералов
@@ -231,8 +361,8 @@ func extractComments(code, language string) string {
 	lines := strings.Split(code, "\n")
 
 	switch language {
-	case "python":
-		// Извлекаем Python-комментарии
+	case "python", "ruby":
+		// Извлекаем комментарии после #
 		for _, line := range lines {
 			line = strings.TrimSpace(line)
 			if strings.HasPrefix(line, "#") {
@@ -246,18 +376,21 @@ func extractComments(code, language string) string {
 		for _, line := range lines {
 			line = strings.TrimSpace(line)
 
-			// Обработка многострочных комментариев
-			if strings.Contains(line, "/*") && strings.Contains(line, "*/") {
-				// Проверяем корректность индексов
-				startIdx := strings.Index(line, "/*")
-				endIdx := strings.Index(line, "*/")
-				if startIdx < endIdx { // Добавляем проверку
-					comment := line[startIdx+2 : endIdx]
-					comments = append(comments, strings.TrimSpace(comment))
+			// Обработка многострочных комментариев. Конец ищем только после "/*",
+			// иначе в "/*/" звездочка открытия приняла бы себя за закрытие
+			startIdx := strings.Index(line, "/*")
+			endIdx := -1
+			if startIdx >= 0 {
+				if i := strings.Index(line[startIdx+2:], "*/"); i >= 0 {
+					endIdx = startIdx + 2 + i
 				}
-			} else if strings.Contains(line, "/*") {
+			}
+
+			if endIdx >= 0 {
+				comment := line[startIdx+2 : endIdx]
+				comments = append(comments, strings.TrimSpace(comment))
+			} else if startIdx >= 0 {
 				inMultilineComment = true
-				startIdx := strings.Index(line, "/*")
 				if startIdx < len(line)-2 { // Добавляем проверку
 					comment := line[startIdx+2:]
 					if comment != "" {
@@ -331,77 +464,25 @@ func extractIdentifiers(code, language string) Identifiers {
 			ids.Constants = append(ids.Constants, match[1])
 		}
 
-	case "python":
-		// Извлекаем переменные (name =)
-		varRegex := regexp.MustCompile(`(\w+)\s*=\s*[^\=]`)
-		for _, match := range varRegex.FindAllStringSubmatch(code, -1) {
-			ids.Variables = append(ids.Variables, match[1])
-		}
-
-		// Извлекаем функции (def name)
-		funcRegex := regexp.MustCompile(`def\s+(\w+)\s*\(`)
-		for _, match := range funcRegex.FindAllStringSubmatch(code, -1) {
-			ids.Functions = append(ids.Functions, match[1])
-		}
-
-		// Извлекаем классы (class name)
-		classRegex := regexp.MustCompile(`class\s+(\w+)`)
-		for _, match := range classRegex.FindAllStringSubmatch(code, -1) {
-			ids.Classes = append(ids.Classes, match[1])
-		}
-
-	case "java":
-		// Извлекаем переменные (type name)
-		varRegex := regexp.MustCompile(`(?m)(private|public|protected)?\s+\w+\s+(\w+)\s*[;=]`)
-		for _, match := range varRegex.FindAllStringSubmatch(code, -1) {
-			ids.Variables = append(ids.Variables, match[2])
-		}
-
-		// Извлекаем методы
-		methodRegex := regexp.MustCompile(`(?m)(private|public|protected)?\s+\w+\s+(\w+)\s*\(`)
-		for _, match := range methodRegex.FindAllStringSubmatch(code, -1) {
-			ids.Functions = append(ids.Functions, match[2])
-		}
-
-		// Извлекаем классы
-		classRegex := regexp.MustCompile(`class\s+(\w+)`)
-		for _, match := range classRegex.FindAllStringSubmatch(code, -1) {
-			ids.Classes = append(ids.Classes, match[1])
-		}
-
-		// Извлекаем интерфейсы
-		interfaceRegex := regexp.MustCompile(`interface\s+(\w+)`)
-		for _, match := range interfaceRegex.FindAllStringSubmatch(code, -1) {
-			ids.Interfaces = append(ids.Interfaces, match[1])
-		}
 	}
 
 	return ids
 }
 
-// Добавляем функцию для сравнения идентификаторов
+// compareIdentifiers сравнивает идентификаторы по категориям. Общие имена
+// считаются с обеих сторон, поэтому оценка не зависит от порядка работ.
 func compareIdentifiers(ids1, ids2 Identifiers) float64 {
 	var matches, total float64
+	add := func(list1, list2 []string) {
+		matches += float64(len(findCommonElements(list1, list2)) + len(findCommonElements(list2, list1)))
+		total += float64(len(list1) + len(list2))
+	}
 
-	// Сравниваем переменные
-	matches += float64(len(findCommonElements(ids1.Variables, ids2.Variables)))
-	total += float64(len(ids1.Variables))
-
-	// Сравниваем функции
-	matches += float64(len(findCommonElements(ids1.Functions, ids2.Functions)))
-	total += float64(len(ids1.Functions))
-
-	// Сравниваем классы
-	matches += float64(len(findCommonElements(ids1.Classes, ids2.Classes)))
-	total += float64(len(ids1.Classes))
-
-	// Сравниваем интерфейсы
-	matches += float64(len(findCommonElements(ids1.Interfaces, ids2.Interfaces)))
-	total += float64(len(ids1.Interfaces))
-
-	// Сравниваем константы
-	matches += float64(len(findCommonElements(ids1.Constants, ids2.Constants)))
-	total += float64(len(ids1.Constants))
+	add(ids1.Variables, ids2.Variables)
+	add(ids1.Functions, ids2.Functions)
+	add(ids1.Classes, ids2.Classes)
+	add(ids1.Interfaces, ids2.Interfaces)
+	add(ids1.Constants, ids2.Constants)
 
 	if total == 0 {
 		return 0
@@ -452,16 +533,6 @@ func analyzeControlFlow(code, language string) ControlFlow {
 		// Создание паттерна управляющих конструкций
 		cf.ControlPattern = createControlPattern(code)
 
-	case "python":
-		// Аналогичный анализ для Python
-		ifRegex := regexp.MustCompile(`\bif\b`)
-		cf.IfCount = len(ifRegex.FindAllString(code, -1))
-
-		forRegex := regexp.MustCompile(`\bfor\b`)
-		cf.ForCount = len(forRegex.FindAllString(code, -1))
-
-		whileRegex := regexp.MustCompile(`\bwhile\b`)
-		cf.WhileCount = len(whileRegex.FindAllString(code, -1))
 	}
 
 	return cf
@@ -504,7 +575,11 @@ func analyzeFunctions(code, language string) FunctionAnalysis {
 			returnType := match[3]
 
 			fa.DeclareOrder = append(fa.DeclareOrder, funcName)
-			fa.ParamCount[funcName] = len(strings.Split(params, ","))
+			if strings.TrimSpace(params) == "" {
+				fa.ParamCount[funcName] = 0
+			} else {
+				fa.ParamCount[funcName] = len(strings.Split(params, ","))
+			}
 			fa.ReturnTypes[funcName] = returnType
 			fa.FunctionSizes[funcName] = calculateFunctionSize(code, funcName)
 		}
@@ -521,10 +596,9 @@ func analyzeImports(code, language string) ImportAnalysis {
 
 	switch language {
 	case "golang":
-		// Анализ импортов Go
-		importRegex := regexp.MustCompile(`import\s*\((.*?)\)`)
-		matches := importRegex.FindStringSubmatch(code)
-		if len(matches) > 1 {
+		// Анализ импортов Go (блок import может занимать несколько строк)
+		importRegex := regexp.MustCompile(`(?s)import\s*\((.*?)\)`)
+		for _, matches := range importRegex.FindAllStringSubmatch(code, -1) {
 			imports := strings.Split(matches[1], "\n")
 			for _, imp := range imports {
 				imp = strings.TrimSpace(imp)
@@ -533,6 +607,12 @@ func analyzeImports(code, language string) ImportAnalysis {
 				}
 			}
 		}
+
+		// Одиночные импорты вида import "fmt"
+		singleImportRegex := regexp.MustCompile(`(?m)^\s*import\s+(\w+\s+)?("[^"]*")`)
+		for _, match := range singleImportRegex.FindAllStringSubmatch(code, -1) {
+			ia.ImportList = append(ia.ImportList, strings.TrimSpace(match[1]+match[2]))
+		}
 	}
 
 	ia.ImportOrder = strings.Join(ia.ImportList, ",")
@@ -604,104 +684,185 @@ func compareControlFlow(cf1, cf2 ControlFlow) float64 {
 
 // Обновляем функцию compareProjects
 func compareProjects(p1, p2 Project) ComparisonResult {
+	if p1.Language != p2.Language {
+		return compareCrossLanguage(p1, p2)
+	}
+
 	result := ComparisonResult{
-		Project1: p1.Name,
-		Project2: p2.Name,
-		Language: p1.Language,
+		Project1:    p1.Name,
+		Project2:    p2.Name,
+		Language:    p1.Language,
+		ArchiveYear: max(p1.ArchiveYear, p2.ArchiveYear),
 	}
 
-	// Базовое сравнение кода
-	result.Similarity = compareTexts(p1.Content, p2.Content)
+	// Метрику считаем только если она доступна у обоих проектов
+	both := func(metric string) bool {
+		if p1.Available[metric] && p2.Available[metric] {
+			return true
+		}
+		result.Unavailable = append(result.Unavailable, metric)
+		return false
+	}
+
+	// Базовое сравнение кода по отпечаткам winnowing
+	// и/или по покрытию Greedy String Tiling
+	if both(metricText) {
+		if config.usesWinnowing() {
+			result.Similarity, result.Matches = compareFingerprints(p1.Fingerprints, p2.Fingerprints)
+		}
+		if config.usesGST() {
+			tokens1, tokens2 := p1.Tokens.TokenPatterns, p2.Tokens.TokenPatterns
+			result.Tiles = greedyStringTiling(tokens1, tokens2, config.MinMatchLength)
+			result.TileCoverage = tileCoverage(result.Tiles, len(tokens1), len(tokens2))
+		}
+		// В режиме gst основной метрикой кода становится покрытие
+		if config.Algorithm == algorithmGST {
+			result.Similarity = result.TileCoverage
+		}
+		result.Containment1, result.Containment2 = containment(result.Matches, result.Tiles, config.Fingerprint.K,
+			len(p1.Tokens.TokenPatterns), len(p2.Tokens.TokenPatterns))
+	}
 
 	// Сравнение комментариев
-	if p1.Comments != "" && p2.Comments != "" {
+	if both(metricComments) {
 		result.CommentSimilarity = compareTexts(p1.Comments, p2.Comments)
 	}
 
 	// Сравнение идентификаторов
-	result.IdentifierSimilarity = compareIdentifiers(p1.Identifiers, p2.Identifiers)
+	if both(metricIdentifiers) {
+		result.IdentifierSimilarity = compareIdentifiers(p1.Identifiers, p2.Identifiers)
+	}
 
 	// Сравнение потока управления
-	result.ControlFlowSimilarity = compareControlFlow(p1.ControlFlow, p2.ControlFlow)
+	if both(metricControlFlow) {
+		result.ControlFlowSimilarity = compareControlFlow(p1.ControlFlow, p2.ControlFlow)
+	}
 
-	// Сравнение функций
-	result.FunctionSimilarity = compareFunctions(p1.Functions, p2.Functions)
+	// Сравнение функций: по структуре тел, если она известна, иначе по именам
+	if both(metricFunctions) {
+		if len(p1.Bodies) > 0 && len(p2.Bodies) > 0 {
+			result.Clones, result.FunctionSimilarity = detectFunctionClones(p1.Bodies, p2.Bodies)
+		} else {
+			result.FunctionSimilarity = compareFunctions(p1.Functions, p2.Functions)
+		}
+	}
 
 	// Сравнение импортов
-	result.ImportSimilarity = compareImports(p1.Imports, p2.Imports)
+	if both(metricImports) {
+		result.ImportSimilarity = compareImports(p1.Imports, p2.Imports)
+	}
 
 	// Сравнение форматирования
-	result.FormatSimilarity = compareFormatting(p1.Formatting, p2.Formatting)
+	if both(metricFormatting) {
+		result.FormatSimilarity = compareFormatting(p1.Formatting, p2.Formatting)
+	}
 
-	return result
-}
+	// Сравнение последовательностей токенов
+	if both(metricTokens) {
+		result.TokenSimilarity = compareTokens(p1.Tokens, p2.Tokens)
+	}
 
-// Добавляем вспомогательную функцию для сравнения текстов
-func compareTexts(text1, text2 string) float64 {
-	s1 := strings.Split(text1, " ")
-	s2 := strings.Split(text2, " ")
+	// Сравнение структуры AST
+	if both(metricAST) {
+		result.ASTSimilarity = compareASTShapes(p1.ASTShape, p2.ASTShape)
+	}
 
-	matches := 0
-	totalTokens := len(s1)
+	// Матрица совпадений файлов внутри пары
+	result.Files1, result.Files2, result.FileMatrix = compareFiles(p1, p2)
+	result.Paths1, result.Paths2 = p1.filePaths(), p2.filePaths()
+	result.Fragments = matchedFragments(p1, p2, result.Matches, result.Tiles)
 
-	for _, token := range s1 {
-		if token == "" {
-			continue
-		}
-		for _, token2 := range s2 {
-			if token == token2 {
-				matches++
-				break
-			}
+	result.OverallScore = config.Scoring.overallScore(result)
+
+	return result
+}
+
+// IsAvailable сообщает, была ли метрика вычислена для пары
+func (r ComparisonResult) IsAvailable(metric string) bool {
+	for _, m := range r.Unavailable {
+		if m == metric {
+			return false
 		}
 	}
+	return true
+}
 
-	if totalTokens == 0 {
+// MetricValue возвращает значение метрики по ее названию
+func (r ComparisonResult) MetricValue(metric string) float64 {
+	switch metric {
+	case metricText:
+		return r.Similarity
+	case metricComments:
+		return r.CommentSimilarity
+	case metricIdentifiers:
+		return r.IdentifierSimilarity
+	case metricControlFlow:
+		return r.ControlFlowSimilarity
+	case metricFunctions:
+		return r.FunctionSimilarity
+	case metricImports:
+		return r.ImportSimilarity
+	case metricFormatting:
+		return r.FormatSimilarity
+	case metricTokens:
+		return r.TokenSimilarity
+	case metricAST:
+		return r.ASTSimilarity
+	}
+	return 0
+}
+
+// compareTexts сравнивает тексты по словам: доля слов каждого текста, найденных
+// в другом, с весом по числу слов. Оценка симметрична.
+func compareTexts(text1, text2 string) float64 {
+	words1 := strings.Fields(text1)
+	words2 := strings.Fields(text2)
+	if len(words1)+len(words2) == 0 {
 		return 0
 	}
 
-	return float64(matches) / float64(totalTokens) * 100
+	matches := len(findCommonElements(words2, words1)) + len(findCommonElements(words1, words2))
+	return float64(matches) / float64(len(words1)+len(words2)) * 100
 }
 
-// Обновим функцию compareAllProjects для вывода прогресса
-func compareAllProjects(projects []Project) []ComparisonResult {
-	var results []ComparisonResult
-	totalComparisons := (len(projects) * (len(projects) - 1)) / 2
-	currentComparison := 0
-
-	fmt.Printf("Начинаю сравнение %d проектов (%d сравнений)...\n",
-		len(projects), totalComparisons)
-
-	for i := 0; i < len(projects); i++ {
-		for j := i + 1; j < len(projects); j++ {
-			currentComparison++
-			fmt.Printf("\rПрогресс: %d/%d сравнений", currentComparison, totalComparisons)
-
-			if projects[i].Language == projects[j].Language {
-				fmt.Printf("\nСравниваю проекты:\n")
-				fmt.Printf("  1. %s (%s)\n", projects[i].Name, projects[i].FilePath)
-				fmt.Printf("  2. %s (%s)\n", projects[j].Name, projects[j].FilePath)
-
-				result := compareProjects(projects[i], projects[j])
-				if result.Similarity > 50 {
-					results = append(results, result)
-					fmt.Printf("  Обнаружена схожесть: %.2f%%\n", result.Similarity)
-				} else {
-					fmt.Printf("  Схожесть ниже порога (%.2f%%)\n", result.Similarity)
-				}
-				fmt.Println("----------------------------------------")
-			}
+// Обновляем функцию printResults для более подробного вывода
+// Результаты приходят все, в консоль и HTML попадают только пары не ниже порога.
+func printResults(projects []ProjectSummary, all []ComparisonResult, stats ComparisonStats) error {
+	// Создаем директорию для отчетов, если она не существует
+	reportDir := config.OutputDir
+	if err := os.MkdirAll(reportDir, os.ModePerm); err != nil {
+		return fmt.Errorf("создание директории отчетов: %w", err)
+	}
+	generatedAt := time.Now()
+	reportName := fmt.Sprintf("plagiarism_report_%s", generatedAt.Format("2006-01-02_15-04-05"))
+	matrix := buildSimilarityMatrix(projects, all)
+	results := config.Scoring.filterResults(all)
+	clusters := findClusters(results, all, config.ClusterMethod)
+
+	// JSON-отчет пишется даже без подозрительных пар: его читают другие программы
+	if containsString(config.Formats, formatJSON) {
+		reportFileName := filepath.Join(reportDir, reportName+".json")
+		if err := writeJSONReport(reportFileName, buildJSONReport(generatedAt, projects, all, stats, matrix, clusters)); err != nil {
+			return err
 		}
+		fmt.Printf("JSON-отчет сохранен в файл: %s\n", reportFileName)
 	}
-	fmt.Printf("\nЗавершено сравнение проектов\n\n")
-	return results
-}
 
-// Обновляем функцию printResults для более подробного вывода
-func printResults(results []ComparisonResult) {
-	if len(results) == 0 {
-		fmt.Println("Подозрительных совпадений не обнаружено")
-		return
+	// Таблицы для разбора в электронных таблицах тоже содержат все пары
+	if containsString(config.Formats, formatCSV) {
+		pairsFile := filepath.Join(reportDir, reportName+"_pairs.csv")
+		if err := writeCSV(pairsFile, pairCSVRows(all, config.Scoring)); err != nil {
+			return err
+		}
+		studentsFile := filepath.Join(reportDir, reportName+"_students.csv")
+		if err := writeCSV(studentsFile, studentCSVRows(projects, all, config.Scoring)); err != nil {
+			return err
+		}
+		matrixFile := filepath.Join(reportDir, reportName+"_matrix.csv")
+		if err := writeCSV(matrixFile, matrix.csvRows()); err != nil {
+			return err
+		}
+		fmt.Printf("CSV-таблицы сохранены в файлы: %s, %s, %s\n", pairsFile, studentsFile, matrixFile)
 	}
 
 	// Вычисляем общую статистику
@@ -710,48 +871,95 @@ func printResults(results []ComparisonResult) {
 	mediumSim := 0
 	lowSim := 0
 
+	scoring := config.Scoring
+	archiveHits := 0
 	for _, result := range results {
-		// Вычисляем среднюю схожесть для каждого сравнения
-		avgSim := (result.Similarity +
-			result.CommentSimilarity +
-			result.IdentifierSimilarity +
-			result.ControlFlowSimilarity +
-			result.FunctionSimilarity +
-			result.ImportSimilarity +
-			result.FormatSimilarity) / 7.0
-
-		totalSimilarity += avgSim
+		totalSimilarity += result.OverallScore
+		if result.ArchiveYear != 0 {
+			archiveHits++
+		}
 
 		// Подсчитываем количество разных уровней схожести
-		if avgSim >= 80 {
+		switch scoring.band(result.OverallScore) {
+		case bandHigh:
 			highSim++
-		} else if avgSim >= 60 {
+		case bandMedium:
 			mediumSim++
-		} else {
+		default:
 			lowSim++
 		}
 	}
 
-	averageSimilarity := totalSimilarity / float64(len(results))
+	// HTML-отчет без подозрительных пар все равно нужен: в нем матрица всей когорты
+	averageSimilarity := 0.0
+	if len(results) == 0 {
+		fmt.Println("Подозрительных совпадений не обнаружено")
+	} else {
+		averageSimilarity = totalSimilarity / float64(len(results))
+
+		// Выводим краткую статистику в консоль
+		fmt.Printf("\nОБЩАЯ СТАТИСТИКА:\n")
+		fmt.Printf("Всего сравнений: %d\n", len(results))
+		fmt.Printf("Средняя схожесть: %.2f%%\n", averageSimilarity)
+		fmt.Printf("Высокая схожесть (≥%g%%): %d проектов\n", scoring.HighBand, highSim)
+		fmt.Printf("Средняя схожесть (%g-%g%%): %d проектов\n", scoring.MediumBand, scoring.HighBand, mediumSim)
+		fmt.Printf("Низкая схожесть (<%g%%): %d проектов\n", scoring.MediumBand, lowSim)
+		if archiveHits > 0 {
+			fmt.Printf("Совпадений с архивом прошлых лет: %d\n", archiveHits)
+		}
+		for i, c := range clusters {
+			fmt.Printf("Группа %d (%d работ, средняя схожесть %.2f%%): %s; вероятный источник - %s\n",
+				i+1, len(c.Members), c.AverageScore, strings.Join(c.Members, ", "), c.LikelySource)
+		}
+	}
 
-	// Выводим краткую статистику в консоль
-	fmt.Printf("\nОБЩАЯ СТАТИСТИКА:\n")
-	fmt.Printf("Всего сравнений: %d\n", len(results))
-	fmt.Printf("Средняя схожесть: %.2f%%\n", averageSimilarity)
-	fmt.Printf("Высокая схожесть (>80%%): %d проектов\n", highSim)
-	fmt.Printf("Средняя схожесть (60-80%%): %d проектов\n", mediumSim)
-	fmt.Printf("Низкая схожесть (<60%%): %d проектов\n", lowSim)
+	// Создаем переменную report типа HtmlReport
+	report := HtmlReport{
+		GeneratedTime:         generatedAt.Format("15:04:05"),
+		GeneratedDate:         generatedAt.Format("2006-01-02"),
+		TotalProjects:         len(projects),
+		TotalComparisons:      len(results),
+		Results:               results,
+		AverageSimilarity:     averageSimilarity,
+		HighSimilarityCount:   highSim,
+		MediumSimilarityCount: mediumSim,
+		LowSimilarityCount:    lowSim,
+		ShowTiles:             config.usesGST(),
+		Projects:              projects,
+		ShowBaseCode:          config.BaseDir != "",
+		ShowCommonCode:        config.CommonFraction > 0,
+		Scoring:               scoring,
+		Stats:                 stats,
+		ArchiveHits:           archiveHits,
+		Heatmaps:              matrix.heatmaps(),
+		Clusters:              clusterViews(clusters, scoring),
+		ClusterMethod:         config.ClusterMethod,
+	}
 
-	// Создаем директорию для отчетов, если она не существует
-	reportDir := "./reports"
-	if err := os.MkdirAll(reportDir, os.ModePerm); err != nil {
-		fmt.Printf("Ошибка при создании директории отчетов: %v\n", err)
-		return
+	for _, format := range config.Formats {
+		switch format {
+		case formatHTML:
+			// Создаем файл отчета с новым именем в директории отчетов
+			reportFileName := filepath.Join(reportDir, reportName+".html")
+			report.PairsDir = reportName + "_pairs"
+			for i, result := range results {
+				page := buildPairPage(i+1, result, scoring, "../"+reportName+".html")
+				if err := writePairPage(filepath.Join(reportDir, report.PairsDir, pairPageName(i+1)), page); err != nil {
+					return err
+				}
+			}
+			if err := writeHTMLReport(reportFileName, report); err != nil {
+				return err
+			}
+			fmt.Printf("\nПодробный отчет сохранен в файл: %s\n", reportFileName)
+		}
 	}
 
-	// Создаем файл отчета с новым именем в директории отчетов
-	reportFileName := fmt.Sprintf("%s/plagiarism_report_%s.html", reportDir, time.Now().Format("2006-01-02_15-04-05"))
+	return nil
+}
 
+// writeHTMLReport записывает HTML-отчет
+func writeHTMLReport(path string, report HtmlReport) error {
 	// Обновляем HTML шаблон, добавляя отображение даты и времени
 	const htmlTemplate = `
 <!DOCTYPE html>
@@ -781,6 +989,18 @@ func printResults(results []ComparisonResult) {
         .high-similarity { background-color: #dc3545; }
         .medium-similarity { background-color: #ffc107; }
         .low-similarity { background-color: #28a745; }
+        .clone { font-size: 0.85em; color: #666; }
+        .archive-hit { background-color: #e7f1ff; }
+        .heatmap { width: auto; border-collapse: collapse; margin: 10px 0; }
+        .heatmap td { width: 14px; height: 14px; padding: 0; border: 1px solid #fff; }
+        .heatmap th { padding: 0 6px; font-size: 0.75em; font-weight: normal; white-space: nowrap; border: none; background: none; }
+        .heatmap th.col { height: 90px; vertical-align: bottom; }
+        .heatmap th.col div { writing-mode: vertical-rl; transform: rotate(180deg); }
+        .archive { font-size: 0.8em; color: #fff; background-color: #0d6efd; padding: 2px 6px; border-radius: 3px; }
+        .file-matrix { font-size: 0.85em; margin: 5px 0; }
+        .cluster { display: inline-block; vertical-align: top; margin: 10px 20px 10px 0; max-width: 420px; }
+        .cluster svg { background-color: #f8f9fa; border-radius: 5px; }
+        .file-matrix th, .file-matrix td { padding: 4px; }
         .datetime {
             font-size: 1.1em;
             color: #666;
@@ -800,10 +1020,14 @@ func printResults(results []ComparisonResult) {
     <div class="summary">
         <h2>Общая статистика</h2>
         <p>Всего проверено сравнений: {{.TotalComparisons}}</p>
+        {{if .ArchiveHits}}<p>Совпадений с архивом прошлых лет: {{.ArchiveHits}}</p>{{end}}
+        {{if .Stats.CrossPairs}}<p>Пар на разных языках: {{.Stats.CrossPairs}}, порог для них: {{.Scoring.CrossThreshold}}%</p>{{end}}
+        {{if .Stats.Pruned}}<p>Отсеяно по индексу отпечатков пар с архивом: {{.Stats.Pruned}} из {{.Stats.TotalPairs}} пар</p>{{end}}
         <p>Средняя схожесть: {{printf "%.2f" .AverageSimilarity}}%</p>
-        <p>Высокая схожесть (>80%): {{.HighSimilarityCount}} проектов</p>
-        <p>Средняя схожесть (60-80%): {{.MediumSimilarityCount}} проектов</p>
-        <p>Низкая схожесть (<60%): {{.LowSimilarityCount}} проектов</p>
+        <p>Порог попадания в отчет: {{.Scoring.Threshold}}%</p>
+        <p>Высокая схожесть (≥{{.Scoring.HighBand}}%): {{.HighSimilarityCount}} проектов</p>
+        <p>Средняя схожесть ({{.Scoring.MediumBand}}-{{.Scoring.HighBand}}%): {{.MediumSimilarityCount}} проектов</p>
+        <p>Низкая схожесть (<{{.Scoring.MediumBand}}%): {{.LowSimilarityCount}} проектов</p>
     </div>
 
     <div class="results">
@@ -817,89 +1041,192 @@ func printResults(results []ComparisonResult) {
                 <th>Схожесть кода</th>
                 <th>Схожесть комментариев</th>
                 <th>Схожесть идентификаторов</th>
+                <th>Поток управления</th>
+                <th>Функции</th>
+                <th>Импорты</th>
+                <th>Форматирование</th>
+                <th>Токены</th>
+                <th>Структура AST</th>
+                <th>Файлы</th>
+                {{if $.ShowTiles}}<th>Покрытие GST</th>{{end}}
             </tr>
             {{range $i, $r := .Results}}
-            <tr>
+            <tr{{if $r.ArchiveYear}} class="archive-hit"{{end}}>
                 <td>{{inc $i}}</td>
-                <td>{{$r.Project1}} и {{$r.Project2}}</td>
+                <td><a href="{{$.PairsDir}}/{{pairPage $i}}">{{$r.Project1}} и {{$r.Project2}}</a>{{if $r.ArchiveYear}} <span class="archive">архив {{$r.ArchiveYear}}</span>{{end}}
+                    {{if $r.CrossLanguage}}<span class="archive">разные языки</span>
+                    <div class="clone">{{printf "%.0f" $r.Containment1}}% {{$r.Project1}} есть в {{$r.Project2}}</div>
+                    <div class="clone">{{printf "%.0f" $r.Containment2}}% {{$r.Project2}} есть в {{$r.Project1}}</div>{{end}}
+                    {{if $r.IsAvailable "text"}}<div class="clone">{{printf "%.0f" $r.Containment1}}% {{$r.Project1}} есть в {{$r.Project2}}</div>
+                    <div class="clone">{{printf "%.0f" $r.Containment2}}% {{$r.Project2}} есть в {{$r.Project1}}</div>{{end}}
+                </td>
                 <td>{{$r.Language}}</td>
                 <td>
                     <div class="similarity-bar">
-                        <div class="similarity-fill {{similarityClass $r.Similarity}}"
-                             style="width: {{$r.Similarity}}%"></div>
+                        <div class="similarity-fill {{similarityClass $r.OverallScore}}"
+                             style="width: {{$r.OverallScore}}%"></div>
                     </div>
-                    {{printf "%.2f" $r.Similarity}}%
+                    {{printf "%.2f" $r.OverallScore}}%
+                </td>
+                <td>{{metric $r "text"}}</td>
+                <td>{{metric $r "comments"}}</td>
+                <td>{{metric $r "identifiers"}}</td>
+                <td>{{metric $r "controlflow"}}</td>
+                <td>
+                    {{metric $r "functions"}}
+                    {{range $r.Clones}}<div class="clone" title="{{.Location1}} ~ {{.Location2}}">{{.Function1}} ~ {{.Function2}} ({{printf "%.0f" .Similarity}}%)</div>{{end}}
+                </td>
+                <td>{{metric $r "imports"}}</td>
+                <td>{{metric $r "formatting"}}</td>
+                <td>{{metric $r "tokens"}}</td>
+                <td>{{metric $r "ast"}}</td>
+                <td>
+                    {{if $r.FileMatrix}}
+                    <details>
+                        <summary>{{len $r.Files1}} × {{len $r.Files2}}</summary>
+                        <table class="file-matrix">
+                            <tr><th></th>{{range $r.Files2}}<th>{{.}}</th>{{end}}</tr>
+                            {{range $fi, $f := $r.Files1}}
+                            <tr><th>{{$f}}</th>{{range index $r.FileMatrix $fi}}<td>{{fileCell .}}</td>{{end}}</tr>
+                            {{end}}
+                        </table>
+                    </details>
+                    {{end}}
                 </td>
-                <td>{{printf "%.2f" $r.Similarity}}%</td>
-                <td>{{printf "%.2f" $r.CommentSimilarity}}%</td>
-                <td>{{printf "%.2f" $r.IdentifierSimilarity}}%</td>
+                {{if $.ShowTiles}}<td>{{printf "%.2f" $r.TileCoverage}}% ({{len $r.Tiles}} отрезков)</td>{{end}}
             </tr>
             {{end}}
         </table>
+        {{if not .Results}}<p>Подозрительных совпадений не обнаружено</p>{{end}}
+    </div>
+
+    {{if .Clusters}}
+    <div class="results">
+        <h2>Группы списывания</h2>
+        <p>Работы, связанные парами выше порога ({{if eq .ClusterMethod "modularity"}}сообщества по модулярности{{else}}компоненты связности{{end}}).
+            Толщина линии растет с оценкой пары, вероятный источник выделен.</p>
+        {{range .Clusters}}
+        <div class="cluster">
+            <h3>Группа {{.Number}}: {{len .Members}} работ, средняя схожесть {{printf "%.2f" .AverageScore}}%</h3>
+            <p>Вероятный источник: <strong>{{.LikelySource}}</strong> ({{.SourceReason}})</p>
+            <svg xmlns="http://www.w3.org/2000/svg" width="{{.Size}}" height="{{.Size}}" viewBox="0 0 {{.Size}} {{.Size}}">
+                {{range .Lines}}<line x1="{{printf "%.1f" .X1}}" y1="{{printf "%.1f" .Y1}}" x2="{{printf "%.1f" .X2}}" y2="{{printf "%.1f" .Y2}}" stroke="{{.Color}}" stroke-width="{{printf "%.1f" .Width}}"><title>{{.Title}}</title></line>
+                {{end}}
+                {{range .Nodes}}<circle cx="{{printf "%.1f" .X}}" cy="{{printf "%.1f" .Y}}" r="{{if .Source}}10{{else}}7{{end}}" fill="{{if .Source}}#dc3545{{else}}#0d6efd{{end}}" stroke="#fff" stroke-width="2"><title>{{.Name}}</title></circle>
+                <text x="{{printf "%.1f" .LabelX}}" y="{{printf "%.1f" .LabelY}}" text-anchor="{{.Anchor}}" font-size="12"{{if .Source}} font-weight="bold"{{end}}>{{.Name}}</text>
+                {{end}}
+            </svg>
+        </div>
+        {{end}}
+    </div>
+    {{end}}
+
+    {{if .Heatmaps}}
+    <div class="results">
+        <h2>Матрица схожести</h2>
+        <p>Порядок работ:
+            {{range .Heatmaps}}<button type="button" onclick="showHeatmap('{{.ID}}')">{{.Title}}</button> {{end}}
+        </p>
+        {{range $k, $h := .Heatmaps}}
+        <table class="heatmap" id="{{$h.ID}}"{{if $k}} style="display: none"{{end}}>
+            <tr><th></th>{{range $h.Names}}<th class="col"><div>{{.}}</div></th>{{end}}</tr>
+            {{range $i, $row := $h.Rows}}
+            <tr><th>{{index $h.Names $i}}</th>{{range $row}}<td style="background: {{.Color}}" title="{{.Title}}"></td>{{end}}</tr>
+            {{end}}
+        </table>
+        {{end}}
+        <p>Серые клетки - работы на разных языках.</p>
+        <script>
+            function showHeatmap(id) {
+                document.querySelectorAll('.heatmap').forEach(function (table) {
+                    table.style.display = table.id === id ? '' : 'none';
+                });
+            }
+        </script>
     </div>
+    {{end}}
+
+    {{if or .ShowBaseCode .ShowCommonCode}}
+    <div class="results">
+        <h2>Исключенный код</h2>
+        <table>
+            <tr>
+                <th>Проект</th>
+                <th>Файлов</th>
+                {{if .ShowBaseCode}}<th>Исключено как шаблон</th>{{end}}
+                {{if .ShowCommonCode}}<th>Подавлено как общий код когорты</th>{{end}}
+            </tr>
+            {{range .Projects}}
+            <tr>
+                <td>{{.Name}}</td>
+                <td>{{len .Files}}</td>
+                {{if $.ShowBaseCode}}<td>{{printf "%.2f" .BaseRemoved}}%</td>{{end}}
+                {{if $.ShowCommonCode}}<td>{{printf "%.2f" .CommonRemoved}}%</td>{{end}}
+            </tr>
+            {{end}}
+        </table>
+    </div>
+    {{end}}
 
     <div class="summary">
         <h2>Выводы</h2>
         {{if gt .HighSimilarityCount 0}}
-        <p style="color: #dc3545">⚠️ Обнаружено {{.HighSimilarityCount}} случаев высокой схожести (более 80%)</p>
+        <p style="color: #dc3545">⚠️ Обнаружено {{.HighSimilarityCount}} случаев высокой схожести (не менее {{.Scoring.HighBand}}%)</p>
         {{end}}
         {{if gt .MediumSimilarityCount 0}}
-        <p style="color: #ffc107">⚠️ Обнаружено {{.MediumSimilarityCount}} случаев средней схожести (60-80%)</p>
+        <p style="color: #ffc107">⚠️ Обнаружено {{.MediumSimilarityCount}} случаев средней схожести ({{.Scoring.MediumBand}}-{{.Scoring.HighBand}}%)</p>
         {{end}}
         {{if gt .LowSimilarityCount 0}}
-        <p style="color: #28a745">ℹ️ Обнаружено {{.LowSimilarityCount}} случаев низкой схожести (менее 60%)</p>
+        <p style="color: #28a745">ℹ️ Обнаружено {{.LowSimilarityCount}} случаев низкой схожести (менее {{.Scoring.MediumBand}}%)</p>
         {{end}}
     </div>
 </body>
 </html>
 `
 
+	scoring := report.Scoring
+
 	// Создаем функции для шаблона
 	funcMap := template.FuncMap{
 		"inc": func(i int) int {
 			return i + 1
 		},
+		"pairPage": func(i int) string {
+			return pairPageName(i + 1)
+		},
 		"similarityClass": func(similarity float64) string {
-			if similarity >= 80 {
-				return "high-similarity"
-			} else if similarity >= 60 {
-				return "medium-similarity"
+			return scoring.band(similarity) + "-similarity"
+		},
+		// Файлы на разных языках не сравниваются
+		"fileCell": func(similarity float64) string {
+			if similarity < 0 {
+				return "—"
+			}
+			return fmt.Sprintf("%.0f%%", similarity)
+		},
+		// Недоступные метрики выводим как "н/д", а не как 0%
+		"metric": func(r ComparisonResult, metric string) string {
+			if !r.IsAvailable(metric) {
+				return "н/д"
 			}
-			return "low-similarity"
+			return fmt.Sprintf("%.2f%%", r.MetricValue(metric))
 		},
 	}
 
 	// Создаем и выполняем шаблон
 	tmpl := template.Must(template.New("report").Funcs(funcMap).Parse(htmlTemplate))
 
-	// Создаем переменную report типа HtmlReport
-	report := HtmlReport{
-		GeneratedTime:         time.Now().Format("15:04:05"),
-		GeneratedDate:         time.Now().Format("2006-01-02"),
-		TotalProjects:         len(results),
-		TotalComparisons:      len(results),
-		Results:               results,
-		AverageSimilarity:     averageSimilarity,
-		HighSimilarityCount:   highSim,
-		MediumSimilarityCount: mediumSim,
-		LowSimilarityCount:    lowSim,
-	}
-
 	// Создаем файл отчета с новым именем
-	file, err := os.Create(reportFileName)
+	file, err := os.Create(path)
 	if err != nil {
-		fmt.Printf("Ошибка при создании файла отчета: %v\n", err)
-		return
+		return fmt.Errorf("создание файла отчета: %w", err)
 	}
 	defer file.Close()
 
-	err = tmpl.Execute(file, report) // Исправлено: теперь передаем report
-	if err != nil {
-		fmt.Printf("Ошибка при генерации отчета: %v\n", err)
-		return
+	if err := tmpl.Execute(file, report); err != nil {
+		return fmt.Errorf("генерация отчета: %w", err)
 	}
-
-	fmt.Printf("\nПодробный отчет сохранен в файл: %s\n", reportFileName)
+	return nil
 }
 
 // Функция для создания паттерна управляющих конструкций
@@ -986,7 +1313,7 @@ func analyzeLineBreaks(code string) string {
 // Функция для удаления комментариев
 func removeComments(code, language string) string {
 	switch language {
-	case "python":
+	case "python", "ruby":
 		lines := strings.Split(code, "\n")
 		var filtered []string
 		for _, line := range lines {
@@ -1013,33 +1340,42 @@ func removeComments(code, language string) string {
 	}
 }
 
-// Функция для сравнения функций
+// compareFunctions сравнивает функции по одноименным характеристикам.
+// Совпадение засчитывается обеим работам, поэтому оценка не зависит от их порядка.
 func compareFunctions(f1, f2 FunctionAnalysis) float64 {
 	total := 0.0
 	matches := 0.0
 
 	// Сравниваем количество параметров
+	total += float64(len(f1.ParamCount) + len(f2.ParamCount))
 	for funcName, count1 := range f1.ParamCount {
 		if count2, exists := f2.ParamCount[funcName]; exists && count1 == count2 {
-			matches++
+			matches += 2
 		}
-		total++
 	}
 
 	// Сравниваем типы возвращаемых значений
+	total += float64(len(f1.ReturnTypes) + len(f2.ReturnTypes))
 	for funcName, type1 := range f1.ReturnTypes {
 		if type2, exists := f2.ReturnTypes[funcName]; exists && type1 == type2 {
-			matches++
+			matches += 2
+		}
+	}
+
+	// Сравниваем сигнатуры, если анализатор их определил
+	total += float64(len(f1.ParamTypes) + len(f2.ParamTypes))
+	for funcName, params1 := range f1.ParamTypes {
+		if params2, exists := f2.ParamTypes[funcName]; exists && params1 == params2 {
+			matches += 2
 		}
-		total++
 	}
 
 	// Сравниваем размеры функций
+	total += float64(len(f1.FunctionSizes) + len(f2.FunctionSizes))
 	for funcName, size1 := range f1.FunctionSizes {
 		if size2, exists := f2.FunctionSizes[funcName]; exists && size1 == size2 {
-			matches++
+			matches += 2
 		}
-		total++
 	}
 
 	// Сравниваем порядок объявления
@@ -1064,15 +1400,15 @@ func compareFunctions(f1, f2 FunctionAnalysis) float64 {
 	return (matches / total) * 100
 }
 
-// Функция для сравнения импортов
+// compareImports сравнивает импорты; общие элементы делятся на размер обоих списков
 func compareImports(i1, i2 ImportAnalysis) float64 {
 	total := 3.0 // три критерия сравнения
 	matches := 0.0
 
 	// Сравниваем списки импортов
-	commonImports := findCommonElements(i1.ImportList, i2.ImportList)
-	if len(commonImports) > 0 {
-		matches += float64(len(commonImports)) / float64(len(i1.ImportList))
+	if imports := len(i1.ImportList) + len(i2.ImportList); imports > 0 {
+		common := len(findCommonElements(i1.ImportList, i2.ImportList)) + len(findCommonElements(i2.ImportList, i1.ImportList))
+		matches += float64(common) / float64(imports)
 	}
 
 	// Сравниваем пордок импортов
@@ -1087,8 +1423,8 @@ func compareImports(i1, i2 ImportAnalysis) float64 {
 			commonPatterns++
 		}
 	}
-	if len(i1.UsagePatterns) > 0 {
-		matches += float64(commonPatterns) / float64(len(i1.UsagePatterns))
+	if patterns := len(i1.UsagePatterns) + len(i2.UsagePatterns); patterns > 0 {
+		matches += float64(2*commonPatterns) / float64(patterns)
 	}
 
 	return (matches / total) * 100
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzeFilePython(t *testing.T) {
+	src := `import os
+from math import sqrt
+
+def add(a, b):
+    if a > b:
+        return a
+    return a + b
+`
+	file := analyzeFile("work/main.py", src, "python")
+
+	if want := []string{"os", "math.sqrt"}; !reflect.DeepEqual(file.Imports.ImportList, want) {
+		t.Errorf("импорты %v, ожидалось %v", file.Imports.ImportList, want)
+	}
+	if want := []string{"add"}; !reflect.DeepEqual(file.Functions.DeclareOrder, want) {
+		t.Errorf("функции %v, ожидалось %v", file.Functions.DeclareOrder, want)
+	}
+	if file.Functions.ParamCount["add"] != 2 {
+		t.Errorf("параметров add: %d, ожидалось 2", file.Functions.ParamCount["add"])
+	}
+	for _, metric := range []string{metricFunctions, metricImports, metricControlFlow, metricIdentifiers} {
+		if !file.Available[metric] {
+			t.Errorf("метрика %s недоступна для Python", metric)
+		}
+	}
+}
+
+func TestExtractComments(t *testing.T) {
+	tests := []struct {
+		name string
+		lang string
+		code string
+		want string
+	}{
+		{"однострочный", "c", "// Счетчик\nx++;", "счетчик"},
+		{"блочный в строке", "c", "int x; /* Размер */ int y;", "размер"},
+		{"многострочный", "c", "/* первая\n   вторая\n*/\nint x;", "первая вторая"},
+		{"открытие и закрытие пересекаются", "c", "/*/00", "/00"},
+		{"закрытие после открытия", "c", "/*/ x */", "/ x"},
+		{"закрытие перед открытием", "c", "*/ a /* b", "b"},
+		{"пустой блок", "c", "/**/", ""},
+		{"Python", "python", "# Решение\nx = 1  # не в начале строки", "решение"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractComments(tt.code, tt.lang); got != tt.want {
+				t.Errorf("extractComments(%q) = %q, ожидалось %q", tt.code, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+)
+
+// Оценка пары, которая не сравнивалась: работы на разных языках без -cross-language
+const matrixNotCompared = -1
+
+// SimilarityMatrix полная матрица общих оценок всех работ текущего запуска.
+// Пары работ запуска индексом не отсеиваются, поэтому без оценки остаются только
+// пары на разных языках. Они получают matrixNotCompared, а не 0: сравнение с
+// оценкой 0 и его отсутствие - не одно и то же.
+type SimilarityMatrix struct {
+	Projects []string
+	Scores   [][]float64
+}
+
+// buildSimilarityMatrix собирает матрицу из результатов сравнения. Архивные
+// работы в матрицу не входят: она показывает только текущую когорту.
+func buildSimilarityMatrix(projects []ProjectSummary, results []ComparisonResult) SimilarityMatrix {
+	index := make(map[string]int, len(projects))
+	m := SimilarityMatrix{
+		Projects: make([]string, len(projects)),
+		Scores:   make([][]float64, len(projects)),
+	}
+	for i, p := range projects {
+		index[p.Name] = i
+		m.Projects[i] = p.Name
+		m.Scores[i] = make([]float64, len(projects))
+		for j := range projects {
+			m.Scores[i][j] = matrixNotCompared
+		}
+		m.Scores[i][i] = 100
+	}
+
+	for _, r := range results {
+		i, ok1 := index[r.Project1]
+		j, ok2 := index[r.Project2]
+		if ok1 && ok2 {
+			m.Scores[i][j] = r.OverallScore
+			m.Scores[j][i] = r.OverallScore
+		}
+	}
+	return m
+}
+
+// clusterOrder упорядочивает работы иерархической кластеризацией со средней
+// связью: работы, похожие друг на друга, оказываются рядом. Расстояние - 100 минус
+// оценка; несравнимые пары максимально далеки.
+func (m SimilarityMatrix) clusterOrder() []int {
+	n := len(m.Projects)
+	if n == 0 {
+		return nil
+	}
+
+	dist := make([][]float64, n)
+	for i := range dist {
+		dist[i] = make([]float64, n)
+		for j := range dist[i] {
+			score := m.Scores[i][j]
+			if score < 0 {
+				score = 0
+			}
+			dist[i][j] = 100 - score
+		}
+	}
+
+	members := make([][]int, n)
+	active := make([]bool, n)
+	for i := range members {
+		members[i] = []int{i}
+		active[i] = true
+	}
+
+	for merges := 0; merges < n-1; merges++ {
+		a, b := -1, -1
+		for i := 0; i < n; i++ {
+			if !active[i] {
+				continue
+			}
+			for j := i + 1; j < n; j++ {
+				if active[j] && (a < 0 || dist[i][j] < dist[a][b]) {
+					a, b = i, j
+				}
+			}
+		}
+
+		// Формула Ланса - Уильямса для средней связи
+		na, nb := float64(len(members[a])), float64(len(members[b]))
+		for k := 0; k < n; k++ {
+			if active[k] && k != a && k != b {
+				d := (na*dist[a][k] + nb*dist[b][k]) / (na + nb)
+				dist[a][k], dist[k][a] = d, d
+			}
+		}
+		members[a] = append(members[a], members[b]...)
+		active[b] = false
+	}
+
+	for i := range active {
+		if active[i] {
+			return members[i]
+		}
+	}
+	return nil
+}
+
+// heatmapCell ячейка тепловой карты
+type heatmapCell struct {
+	Color template.CSS
+	Title string
+}
+
+// heatmapView матрица в одном порядке строк и столбцов
+type heatmapView struct {
+	ID    string
+	Title string
+	Names []string
+	Rows  [][]heatmapCell
+}
+
+// heatmapColor окрашивает оценку от белого (0%) до красного (100%); несравненные
+// пары закрашиваются серым
+func heatmapColor(score float64) template.CSS {
+	if score < 0 {
+		return "#e9ecef"
+	}
+	level := int(255 * (1 - score/100))
+	return template.CSS(fmt.Sprintf("rgb(255, %d, %d)", level, level))
+}
+
+// heatmap строит тепловую карту матрицы в заданном порядке работ
+func (m SimilarityMatrix) heatmap(id, title string, order []int) heatmapView {
+	view := heatmapView{ID: id, Title: title}
+	for _, i := range order {
+		view.Names = append(view.Names, m.Projects[i])
+		row := make([]heatmapCell, 0, len(order))
+		for _, j := range order {
+			score := m.Scores[i][j]
+			cell := heatmapCell{Color: heatmapColor(score)}
+			if score < 0 {
+				cell.Title = fmt.Sprintf("%s ~ %s: разные языки", m.Projects[i], m.Projects[j])
+			} else {
+				cell.Title = fmt.Sprintf("%s ~ %s: %.2f%%", m.Projects[i], m.Projects[j], score)
+			}
+			row = append(row, cell)
+		}
+		view.Rows = append(view.Rows, row)
+	}
+	return view
+}
+
+// heatmaps возвращает тепловые карты в порядке имен и в порядке кластеров
+func (m SimilarityMatrix) heatmaps() []heatmapView {
+	byName := make([]int, len(m.Projects))
+	for i := range byName {
+		byName[i] = i
+	}
+	return []heatmapView{
+		m.heatmap("heatmap-name", "по именам", byName),
+		m.heatmap("heatmap-cluster", "по кластерам", m.clusterOrder()),
+	}
+}
+
+// csvRows переводит матрицу в таблицу; несравнимые пары - пустые ячейки
+func (m SimilarityMatrix) csvRows() [][]string {
+	rows := [][]string{append([]string{""}, m.Projects...)}
+	for i, name := range m.Projects {
+		row := []string{name}
+		for _, score := range m.Scores[i] {
+			if score < 0 {
+				row = append(row, "")
+			} else {
+				row = append(row, csvNumber(score))
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
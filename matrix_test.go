@@ -0,0 +1,100 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildSimilarityMatrixMarksPairsNotCompared(t *testing.T) {
+	projects := []ProjectSummary{
+		{Name: "a", Language: "python"},
+		{Name: "b", Language: "python"},
+		{Name: "c", Language: "java"},
+	}
+	// c на другом языке и без -cross-language не сравнивалась
+	results := []ComparisonResult{
+		{Project1: "a", Project2: "b", OverallScore: 42},
+	}
+
+	tests := []struct {
+		i, j int
+		want float64
+	}{
+		{0, 0, 100},
+		{0, 1, 42},
+		{0, 2, matrixNotCompared},
+		{1, 2, matrixNotCompared},
+	}
+	m := buildSimilarityMatrix(projects, results)
+	for _, tt := range tests {
+		if got := m.Scores[tt.i][tt.j]; got != tt.want {
+			t.Errorf("оценка (%s, %s) = %v, ожидалось %v", m.Projects[tt.i], m.Projects[tt.j], got, tt.want)
+		}
+		if m.Scores[tt.i][tt.j] != m.Scores[tt.j][tt.i] {
+			t.Errorf("матрица несимметрична в (%d, %d)", tt.i, tt.j)
+		}
+	}
+
+	cell := m.heatmap("h", "", []int{0, 1, 2}).Rows[0][2]
+	if !strings.Contains(cell.Title, "разные языки") || cell.Color == heatmapColor(0) {
+		t.Errorf("несравненная пара нарисована как сравненная: %+v", cell)
+	}
+	if row := m.csvRows()[1]; row[3] != "" || row[2] != csvNumber(42) {
+		t.Errorf("строка CSV %v: несравненная пара должна быть пустой ячейкой", row)
+	}
+}
+
+// Матрица когорты полная: индекс не отсеивает пары работ текущего запуска,
+// даже если общих отпечатков у них нет
+func TestCompareAllProjectsFillsMatrix(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+	config.MinShared = 1
+	config.CacheDir = ""
+
+	projects := engineProjects(4)
+	projects = append(projects, languageProject("archive", "python"))
+	projects[len(projects)-1].ArchiveYear = 2020
+	results, stats := compareAllProjects(projects, 2, nil)
+	if stats.Pruned != 4 {
+		t.Errorf("отсеяно %d пар, ожидались только 4 пары с пустой архивной работой", stats.Pruned)
+	}
+
+	summaries := summarizeProjects(projects[:4])
+	m := buildSimilarityMatrix(summaries, results)
+	for i := range m.Scores {
+		for j, score := range m.Scores[i] {
+			if score < 0 {
+				t.Errorf("пара (%s, %s) не сравнивалась", m.Projects[i], m.Projects[j])
+			}
+		}
+	}
+}
+
+func TestHTMLReportKeepsMatrixWithoutSuspiciousPairs(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+	config.OutputDir = t.TempDir()
+	config.Formats = []string{formatHTML}
+	config.Scoring.Threshold = 90
+
+	projects := []ProjectSummary{{Name: "a", Language: "golang"}, {Name: "b", Language: "golang"}}
+	all := []ComparisonResult{{Project1: "a", Project2: "b", Language: "golang", OverallScore: 10}}
+	if err := printResults(projects, all, ComparisonStats{TotalPairs: 1, Compared: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	reports, _ := filepath.Glob(filepath.Join(config.OutputDir, "*.html"))
+	if len(reports) != 1 {
+		t.Fatalf("HTML-отчетов: %d, ожидался один", len(reports))
+	}
+	data, err := ioutil.ReadFile(reports[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "Матрица схожести") {
+		t.Error("в отчете без подозрительных пар нет матрицы схожести")
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Цвета подсветки фрагментов, по кругу
+var fragmentColors = []string{
+	"#ffe08a", "#a8e6cf", "#ffb3ba", "#bae1ff", "#d7b8f3", "#ffd6a5", "#caffbf", "#f1c0e8",
+}
+
+// pairPageLine строка исходника на странице пары
+type pairPageLine struct {
+	Number   int
+	Text     string
+	Fragment int   // номер фрагмента с 1, 0 - строка не совпала
+	Anchors  []int // фрагменты, которые начинаются на этой строке: на них ведут ссылки с другой стороны
+}
+
+// pairPageFile исходник одной стороны на странице пары
+type pairPageFile struct {
+	Name    string
+	Lines   []pairPageLine
+	Missing string // почему исходник нельзя показать
+}
+
+// pairPageBlock два файла пары с совпадениями, показываемые рядом
+type pairPageBlock struct {
+	Left, Right pairPageFile
+}
+
+// pairPageFragment строка списка фрагментов
+type pairPageFragment struct {
+	Number       int
+	File1, File2 string
+	Start1, End1 SourcePos
+	Start2, End2 SourcePos
+	Tokens       int
+}
+
+// PairPage данные страницы с исходниками пары
+type PairPage struct {
+	Number     int
+	Result     ComparisonResult
+	Band       string
+	ReportLink string
+	Fragments  []pairPageFragment
+	Blocks     []pairPageBlock
+}
+
+// pairPageName возвращает имя файла страницы пары по ее номеру в отчете
+func pairPageName(number int) string {
+	return fmt.Sprintf("pair_%d.html", number)
+}
+
+// buildPairPage собирает страницу пары: исходники читаются с диска по путям из результата
+func buildPairPage(number int, r ComparisonResult, scoring ScoringConfig, reportLink string) PairPage {
+	page := PairPage{
+		Number:     number,
+		Result:     r,
+		Band:       bandLabels[scoring.band(r.OverallScore)],
+		ReportLink: reportLink,
+	}
+
+	sources := make(map[string][]string)
+	errors := make(map[string]error)
+	readSource := func(path string) ([]string, error) {
+		if lines, ok := sources[path]; ok {
+			return lines, errors[path]
+		}
+		content, err := ioutil.ReadFile(path)
+		lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+		sources[path], errors[path] = lines, err
+		return lines, err
+	}
+
+	// Фрагменты группируются по паре файлов в порядке первого появления
+	var order [][2]int
+	byFiles := make(map[[2]int][]int)
+	for i, f := range r.Fragments {
+		key := [2]int{f.File1, f.File2}
+		if _, seen := byFiles[key]; !seen {
+			order = append(order, key)
+		}
+		byFiles[key] = append(byFiles[key], i+1)
+
+		page.Fragments = append(page.Fragments, pairPageFragment{
+			Number: i + 1,
+			File1:  fileName(r.Files1, f.File1),
+			File2:  fileName(r.Files2, f.File2),
+			Start1: f.Start1,
+			End1:   f.End1,
+			Start2: f.Start2,
+			End2:   f.End2,
+			Tokens: f.Tokens,
+		})
+	}
+
+	side := func(names, paths []string, file int, numbers []int, left bool) pairPageFile {
+		view := pairPageFile{Name: fileName(names, file)}
+		if file >= len(paths) {
+			view.Missing = "путь к файлу не сохранен"
+			return view
+		}
+		lines, err := readSource(paths[file])
+		if err != nil {
+			view.Missing = fmt.Sprintf("исходник недоступен: %v", err)
+			return view
+		}
+		view.Lines = make([]pairPageLine, len(lines))
+		for i, text := range lines {
+			view.Lines[i] = pairPageLine{Number: i + 1, Text: text}
+		}
+		// Строка, входящая в несколько фрагментов, окрашивается первым из них,
+		// но якорь получает каждый фрагмент, даже если его начало уже окрашено
+		for _, n := range numbers {
+			f := r.Fragments[n-1]
+			start, end := f.Start1.Line, f.End1.Line
+			if !left {
+				start, end = f.Start2.Line, f.End2.Line
+			}
+			if start >= 1 && start <= len(lines) {
+				view.Lines[start-1].Anchors = append(view.Lines[start-1].Anchors, n)
+			}
+			for line := start; line <= end && line <= len(lines); line++ {
+				if line < 1 || view.Lines[line-1].Fragment != 0 {
+					continue
+				}
+				view.Lines[line-1].Fragment = n
+			}
+		}
+		return view
+	}
+
+	for _, key := range order {
+		numbers := byFiles[key]
+		page.Blocks = append(page.Blocks, pairPageBlock{
+			Left:  side(r.Files1, r.Paths1, key[0], numbers, true),
+			Right: side(r.Files2, r.Paths2, key[1], numbers, false),
+		})
+	}
+	return page
+}
+
+// fileName возвращает имя файла работы по индексу
+func fileName(names []string, i int) string {
+	if i < len(names) {
+		return names[i]
+	}
+	return fmt.Sprintf("файл %d", i+1)
+}
+
+// writePairPage сохраняет страницу пары с исходниками рядом
+func writePairPage(path string, page PairPage) error {
+	const pairTemplate = `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>{{.Result.Project1}} и {{.Result.Project2}}</title>
+    <style>
+        body { font-family: Arial, sans-serif; margin: 20px; }
+        .header { background-color: #f8f9fa; padding: 20px; border-radius: 5px; }
+        table { border-collapse: collapse; margin: 20px 0; }
+        th, td { padding: 6px 10px; border: 1px solid #dee2e6; text-align: left; }
+        th { background-color: #f8f9fa; }
+        .side { display: flex; gap: 10px; margin: 10px 0 30px; }
+        .side > div { flex: 1; overflow-x: auto; }
+        .code { width: 100%; margin: 0; font-family: monospace; font-size: 0.85em; }
+        .code td { border: none; padding: 0 6px; white-space: pre; vertical-align: top; }
+        .code td.ln { color: #999; text-align: right; user-select: none; width: 1%; }
+        .code td.ln a { color: #333; font-weight: bold; text-decoration: none; }
+        .missing { color: #dc3545; }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <p><a href="{{.ReportLink}}">← к отчету</a></p>
+        <h1>{{.Result.Project1}} и {{.Result.Project2}}</h1>
+        <p>Общая оценка: {{printf "%.2f" .Result.OverallScore}}% ({{.Band}}), язык: {{.Result.Language}}</p>
+        {{if .Result.CrossLanguage}}<p>Работы на разных языках сравнены по промежуточному представлению (управляющие конструкции, операции, вызовы):
+            {{printf "%.2f" .Result.Containment1}}% {{.Result.Project1}} найдено в {{.Result.Project2}},
+            {{printf "%.2f" .Result.Containment2}}% {{.Result.Project2}} - в {{.Result.Project1}}</p>{{end}}
+        {{if .Result.IsAvailable "text"}}<p>{{printf "%.2f" .Result.Containment1}}% кода {{.Result.Project1}} найдено в {{.Result.Project2}},
+            {{printf "%.2f" .Result.Containment2}}% кода {{.Result.Project2}} - в {{.Result.Project1}}</p>{{end}}
+        {{if .Result.ArchiveYear}}<p>Вторая работа - из архива {{.Result.ArchiveYear}} года</p>{{end}}
+    </div>
+
+    {{if .Fragments}}
+    <h2>Совпавшие фрагменты</h2>
+    <table>
+        <tr><th>№</th><th>{{.Result.Project1}}</th><th>{{.Result.Project2}}</th><th>Токенов</th></tr>
+        {{range .Fragments}}
+        <tr style="background-color: {{fragmentColor .Number}}">
+            <td>{{.Number}}</td>
+            <td><a href="#a{{.Number}}">{{.File1}}:{{.Start1.Line}}:{{.Start1.Column}}-{{.End1.Line}}:{{.End1.Column}}</a></td>
+            <td><a href="#b{{.Number}}">{{.File2}}:{{.Start2.Line}}:{{.Start2.Column}}-{{.End2.Line}}:{{.End2.Column}}</a></td>
+            <td>{{.Tokens}}</td>
+        </tr>
+        {{end}}
+    </table>
+    {{else}}
+    <p>Совпавших фрагментов кода не найдено: оценка получена по другим метрикам.</p>
+    {{end}}
+
+    {{range .Blocks}}
+    <h3>{{.Left.Name}} ↔ {{.Right.Name}}</h3>
+    <div class="side">
+        <div>{{template "source" dict "File" .Left "Own" "a" "Other" "b"}}</div>
+        <div>{{template "source" dict "File" .Right "Own" "b" "Other" "a"}}</div>
+    </div>
+    {{end}}
+</body>
+</html>
+
+{{define "source"}}
+{{with .File}}{{if .Missing}}<p class="missing">{{.Name}}: {{.Missing}}</p>{{else}}
+<table class="code">
+    {{range .Lines}}
+    <tr{{if .Fragment}} style="background-color: {{fragmentColor .Fragment}}"{{end}}>
+        <td class="ln">{{range .Anchors}}<span id="{{$.Own}}{{.}}"></span>{{end}}{{if .Fragment}}<a href="#{{$.Other}}{{.Fragment}}" title="фрагмент {{.Fragment}}">{{.Number}}</a>{{else}}{{.Number}}{{end}}</td>
+        <td>{{.Text}}</td>
+    </tr>
+    {{end}}
+</table>
+{{end}}{{end}}
+{{end}}
+`
+
+	funcMap := template.FuncMap{
+		"fragmentColor": func(n int) template.CSS {
+			return template.CSS(fragmentColors[(n-1)%len(fragmentColors)])
+		},
+		// Параметры вложенного шаблона
+		"dict": func(pairs ...interface{}) map[string]interface{} {
+			values := make(map[string]interface{})
+			for i := 0; i+1 < len(pairs); i += 2 {
+				values[pairs[i].(string)] = pairs[i+1]
+			}
+			return values
+		},
+	}
+
+	tmpl := template.Must(template.New("pair").Funcs(funcMap).Parse(pairTemplate))
+
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return fmt.Errorf("создание директории страниц пар: %w", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("создание страницы пары: %w", err)
+	}
+	defer file.Close()
+
+	if err := tmpl.Execute(file, page); err != nil {
+		return fmt.Errorf("генерация страницы пары: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fragment совпадение строк s1-e1 файла file1 со строками s2-e2 файла file2
+func fragment(file1, s1, e1, file2, s2, e2 int) MatchedFragment {
+	line := func(n int) SourcePos { return SourcePos{Line: n, Column: 1} }
+	return MatchedFragment{File1: file1, Start1: line(s1), End1: line(e1), File2: file2, Start2: line(s2), End2: line(e2), Tokens: 10}
+}
+
+// writeSources сохраняет исходники во временную папку и возвращает их пути
+func writeSources(t *testing.T, sources ...string) []string {
+	t.Helper()
+	dir := t.TempDir()
+	var paths []string
+	for i, src := range sources {
+		path := filepath.Join(dir, string(rune('a'+i))+".py")
+		if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// lineFragments возвращает номера фрагментов строк и строки, на которых стоят якоря
+func lineFragments(view pairPageFile) (fragments []int, anchors []int) {
+	for _, line := range view.Lines {
+		fragments = append(fragments, line.Fragment)
+		for range line.Anchors {
+			anchors = append(anchors, line.Number)
+		}
+	}
+	return fragments, anchors
+}
+
+func TestBuildPairPage(t *testing.T) {
+	paths := writeSources(t, "a\nb\nc\nd\ne\n", "x\ny\nz\n", "p\nq\nr\ns\n")
+	r := ComparisonResult{
+		Project1: "alice", Project2: "bob",
+		Files1: []string{"main.py", "util.py"}, Paths1: []string{paths[0]},
+		Files2: []string{"solve.py"}, Paths2: []string{paths[2]},
+		Fragments: []MatchedFragment{
+			fragment(0, 2, 3, 0, 1, 2),
+			fragment(1, 1, 2, 0, 3, 3),
+			fragment(0, 5, 5, 0, 4, 4),
+		},
+		OverallScore: 80,
+	}
+	page := buildPairPage(3, r, defaultScoringConfig(), "report.html")
+
+	if len(page.Fragments) != 3 || page.Fragments[1].File1 != "util.py" || page.Fragments[1].File2 != "solve.py" {
+		t.Fatalf("список фрагментов %+v", page.Fragments)
+	}
+	if len(page.Blocks) != 2 {
+		t.Fatalf("блоков %d, ожидалось 2: фрагменты группируются по паре файлов", len(page.Blocks))
+	}
+
+	left, right := page.Blocks[0].Left, page.Blocks[0].Right
+	fragments, anchors := lineFragments(left)
+	if want := []int{0, 1, 1, 0, 3}; !reflect.DeepEqual(fragments, want) || !reflect.DeepEqual(anchors, []int{2, 5}) {
+		t.Errorf("левая сторона: фрагменты %v, якоря %v", fragments, anchors)
+	}
+	fragments, anchors = lineFragments(right)
+	if want := []int{1, 1, 0, 3}; !reflect.DeepEqual(fragments, want) || !reflect.DeepEqual(anchors, []int{1, 4}) {
+		t.Errorf("правая сторона: фрагменты %v, якоря %v", fragments, anchors)
+	}
+
+	if missing := page.Blocks[1].Left; missing.Name != "util.py" || missing.Missing == "" || missing.Lines != nil {
+		t.Errorf("файл без сохраненного пути: %+v", missing)
+	}
+
+	out := filepath.Join(t.TempDir(), "pairs", pairPageName(page.Number))
+	if err := writePairPage(out, page); err != nil {
+		t.Fatal(err)
+	}
+	html, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`id="a1"`, `id="b3"`, `href="#b1"`, `href="#a3"`, `href="report.html"`} {
+		if !strings.Contains(string(html), want) {
+			t.Errorf("на странице пары нет %s", want)
+		}
+	}
+}
+
+func TestBuildPairPageOverlappingFragments(t *testing.T) {
+	paths := writeSources(t, "a\nb\nc\nd\n", "w\nx\ny\nz\n")
+	r := ComparisonResult{
+		Files1: []string{"main.py"}, Paths1: paths[:1],
+		Files2: []string{"main.py"}, Paths2: paths[1:],
+		Fragments: []MatchedFragment{
+			fragment(0, 1, 3, 0, 1, 2),
+			fragment(0, 2, 4, 0, 2, 4),
+			fragment(0, 2, 2, 0, 4, 4),
+		},
+	}
+	page := buildPairPage(1, r, defaultScoringConfig(), "report.html")
+
+	left := page.Blocks[0].Left
+	if got := left.Lines[1].Anchors; !reflect.DeepEqual(got, []int{2, 3}) {
+		t.Errorf("якоря строки 2 слева %v, ожидались фрагменты 2 и 3", got)
+	}
+	fragments, anchors := lineFragments(page.Blocks[0].Right)
+	if want := []int{1, 1, 2, 2}; !reflect.DeepEqual(fragments, want) || !reflect.DeepEqual(anchors, []int{1, 2, 4}) {
+		t.Errorf("правая сторона: фрагменты %v, якоря на строках %v", fragments, anchors)
+	}
+
+	out := filepath.Join(t.TempDir(), pairPageName(page.Number))
+	if err := writePairPage(out, page); err != nil {
+		t.Fatal(err)
+	}
+	html, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"a1", "a2", "a3", "b1", "b2", "b3"} {
+		if n := strings.Count(string(html), `id="`+id+`"`); n != 1 {
+			t.Errorf("якорь %s встречается %d раз, ожидался один", id, n)
+		}
+	}
+}
+
+func TestBuildPairPageMissingSource(t *testing.T) {
+	r := ComparisonResult{
+		Files1: []string{"main.py"}, Paths1: []string{filepath.Join(t.TempDir(), "deleted.py")},
+		Files2: []string{"main.py"}, Paths2: writeSources(t, "x\n"),
+		Fragments: []MatchedFragment{fragment(0, 1, 1, 0, 1, 1)},
+	}
+	page := buildPairPage(1, r, defaultScoringConfig(), "report.html")
+	if len(page.Blocks) != 1 || !strings.Contains(page.Blocks[0].Left.Missing, "недоступен") || page.Blocks[0].Right.Missing != "" {
+		t.Errorf("блоки %+v", page.Blocks)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Файл в папке отчетов, где сохраняется состояние последнего запуска scan
+const runStateFile = "last_run.json"
+
+// runState результаты запуска scan, по которым команды report и explain
+// строят вывод без повторного анализа исходников
+type runState struct {
+	GeneratedAt time.Time
+	Config      Config
+	Projects    []ProjectSummary
+	Results     []ComparisonResult // все сравненные пары, а не только выше порога
+	Stats       ComparisonStats
+}
+
+// saveRunState сохраняет состояние запуска в папку отчетов
+func saveRunState(dir string, state runState) error {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("создание директории отчетов: %w", err)
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("сериализация результатов: %w", err)
+	}
+
+	path := filepath.Join(dir, runStateFile)
+	if err := ioutil.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("запись %s: %w", path, err)
+	}
+	return nil
+}
+
+// loadRunState читает состояние последнего запуска из папки отчетов
+func loadRunState(dir string) (runState, error) {
+	var state runState
+
+	path := filepath.Join(dir, runStateFile)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return state, fmt.Errorf("чтение результатов (сначала выполните scan): %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("разбор %s: %w", path, err)
+	}
+	// Запуски до появления настройки расширений сохранены без нее
+	if state.Config.Extensions == nil {
+		state.Config.Extensions = supportedExtensions
+	}
+	return state, nil
+}
+
+// findResult ищет результат сравнения пары в любом порядке имен
+func findResult(results []ComparisonResult, name1, name2 string) (ComparisonResult, bool) {
+	for _, r := range results {
+		if (r.Project1 == name1 && r.Project2 == name2) || (r.Project1 == name2 && r.Project2 == name1) {
+			return r, true
+		}
+	}
+	return ComparisonResult{}, false
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ScoringConfig задает, как метрики сводятся в общую оценку пары
+type ScoringConfig struct {
+	Weights        map[string]float64 // вес каждой метрики; метрики с нулевым весом не учитываются
+	Threshold      float64            // пары с общей оценкой ниже порога не попадают в отчет
+	CrossThreshold float64            // порог для пар на разных языках, сравненных по промежуточному представлению
+	HighBand       float64            // нижняя граница высокой схожести
+	MediumBand     float64            // нижняя граница средней схожести
+}
+
+// Уровни схожести
+const (
+	bandHigh   = "high"
+	bandMedium = "medium"
+	bandLow    = "low"
+)
+
+// bandLabels задает названия уровней схожести для отчетов
+var bandLabels = map[string]string{
+	bandHigh:   "высокая схожесть",
+	bandMedium: "средняя схожесть",
+	bandLow:    "низкая схожесть",
+}
+
+// defaultScoringConfig возвращает веса и пороги по умолчанию.
+// Структурные метрики устойчивы к переименованию и весят больше остальных.
+// Порог для разных языков подобран по переносам решений: построчный перенос
+// дает 70-100% схожести IR, а несвязанные программы - не больше 25%.
+func defaultScoringConfig() ScoringConfig {
+	return ScoringConfig{
+		Weights: map[string]float64{
+			metricText:        3,
+			metricTokens:      2,
+			metricAST:         2,
+			metricFunctions:   2,
+			metricIdentifiers: 1,
+			metricControlFlow: 1,
+			metricComments:    1,
+			metricImports:     0.5,
+			metricFormatting:  0.5,
+		},
+		Threshold:      50,
+		CrossThreshold: 50,
+		HighBand:       80,
+		MediumBand:     60,
+	}
+}
+
+// validate проверяет веса и пороги
+func (s ScoringConfig) validate() error {
+	for metric, weight := range s.Weights {
+		if !isKnownMetric(metric) {
+			return fmt.Errorf("неизвестная метрика %q в весах (допустимо: %s)", metric, strings.Join(allMetrics, ", "))
+		}
+		if weight < 0 {
+			return fmt.Errorf("вес метрики %s не может быть отрицательным", metric)
+		}
+	}
+	if s.Threshold < 0 || s.Threshold > 100 {
+		return fmt.Errorf("порог должен быть от 0 до 100, получено %g", s.Threshold)
+	}
+	if s.CrossThreshold < 0 || s.CrossThreshold > 100 {
+		return fmt.Errorf("порог для разных языков должен быть от 0 до 100, получено %g", s.CrossThreshold)
+	}
+	if s.MediumBand > s.HighBand {
+		return fmt.Errorf("граница средней схожести (%g) больше границы высокой (%g)", s.MediumBand, s.HighBand)
+	}
+	return nil
+}
+
+// isKnownMetric проверяет название метрики
+func isKnownMetric(metric string) bool {
+	for _, m := range allMetrics {
+		if m == metric {
+			return true
+		}
+	}
+	return false
+}
+
+// overallScore считает взвешенное среднее доступных для пары метрик. У пары на
+// разных языках есть только схожесть промежуточного представления.
+func (s ScoringConfig) overallScore(r ComparisonResult) float64 {
+	if r.CrossLanguage {
+		return r.IRSimilarity
+	}
+	var sum, totalWeight float64
+	for _, metric := range allMetrics {
+		weight := s.Weights[metric]
+		if weight == 0 || !r.IsAvailable(metric) {
+			continue
+		}
+		sum += r.MetricValue(metric) * weight
+		totalWeight += weight
+	}
+	if totalWeight == 0 {
+		return 0
+	}
+	return sum / totalWeight
+}
+
+// band определяет уровень схожести по общей оценке
+func (s ScoringConfig) band(score float64) string {
+	if score >= s.HighBand {
+		return bandHigh
+	} else if score >= s.MediumBand {
+		return bandMedium
+	}
+	return bandLow
+}
+
+// threshold возвращает порог для пары: у пар на разных языках он свой
+func (s ScoringConfig) threshold(r ComparisonResult) float64 {
+	if r.CrossLanguage {
+		return s.CrossThreshold
+	}
+	return s.Threshold
+}
+
+// flagged сообщает, что общая оценка пары не ниже ее порога
+func (s ScoringConfig) flagged(r ComparisonResult) bool {
+	return r.OverallScore >= s.threshold(r)
+}
+
+// filterResults оставляет пары с общей оценкой не ниже порога
+func (s ScoringConfig) filterResults(results []ComparisonResult) []ComparisonResult {
+	var flagged []ComparisonResult
+	for _, result := range results {
+		if s.flagged(result) {
+			flagged = append(flagged, result)
+		}
+	}
+	return flagged
+}
+
+// rescore пересчитывает общие оценки с текущими весами
+func (s ScoringConfig) rescore(results []ComparisonResult) {
+	for i := range results {
+		results[i].OverallScore = s.overallScore(results[i])
+	}
+}
+
+// parseWeights разбирает веса вида "text=3,tokens=2"
+func parseWeights(spec string) (map[string]float64, error) {
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("ожидалось метрика=вес, получено %q", part)
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("некорректный вес метрики %s: %w", name, err)
+		}
+		weights[strings.TrimSpace(name)] = weight
+	}
+	return weights, nil
+}
+
+// formatWeights печатает веса в том же виде, в каком их принимает parseWeights
+func formatWeights(weights map[string]float64) string {
+	var parts []string
+	for metric, weight := range weights {
+		parts = append(parts, fmt.Sprintf("%s=%g", metric, weight))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
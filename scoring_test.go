@@ -0,0 +1,104 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOverallScore(t *testing.T) {
+	s := ScoringConfig{Weights: map[string]float64{metricText: 3, metricTokens: 1, metricComments: 1}}
+	tests := []struct {
+		name   string
+		result ComparisonResult
+		want   float64
+	}{
+		{"взвешенное среднее", ComparisonResult{Similarity: 80, TokenSimilarity: 40, CommentSimilarity: 20}, 60},
+		{
+			"недоступная метрика не учитывается",
+			ComparisonResult{Similarity: 80, TokenSimilarity: 40, CommentSimilarity: 0, Unavailable: []string{metricComments}},
+			70,
+		},
+		{"нет доступных метрик", ComparisonResult{Unavailable: []string{metricText, metricTokens, metricComments}}, 0},
+		{"разные языки - только IR", ComparisonResult{CrossLanguage: true, IRSimilarity: 55, Similarity: 100}, 55},
+	}
+	for _, tt := range tests {
+		if got := s.overallScore(tt.result); got != tt.want {
+			t.Errorf("%s: %.2f, ожидалось %.2f", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBand(t *testing.T) {
+	s := defaultScoringConfig()
+	tests := []struct {
+		score float64
+		want  string
+	}{
+		{100, bandHigh}, {s.HighBand, bandHigh}, {s.HighBand - 0.01, bandMedium},
+		{s.MediumBand, bandMedium}, {s.MediumBand - 0.01, bandLow}, {0, bandLow},
+	}
+	for _, tt := range tests {
+		if got := s.band(tt.score); got != tt.want {
+			t.Errorf("band(%g) = %s, ожидалось %s", tt.score, got, tt.want)
+		}
+	}
+}
+
+func TestFilterResultsUsesPairThreshold(t *testing.T) {
+	s := ScoringConfig{Threshold: 50, CrossThreshold: 70}
+	results := []ComparisonResult{
+		{Project1: "a", OverallScore: 50},
+		{Project1: "b", OverallScore: 49.9},
+		{Project1: "c", OverallScore: 60, CrossLanguage: true},
+		{Project1: "d", OverallScore: 70, CrossLanguage: true},
+	}
+	var names []string
+	for _, r := range s.filterResults(results) {
+		names = append(names, r.Project1)
+	}
+	if want := []string{"a", "d"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("отобраны %v, ожидалось %v", names, want)
+	}
+}
+
+func TestScoringValidate(t *testing.T) {
+	valid := defaultScoringConfig()
+	if err := valid.validate(); err != nil {
+		t.Fatalf("настройки по умолчанию: %v", err)
+	}
+	tests := []struct {
+		name   string
+		modify func(*ScoringConfig)
+	}{
+		{"неизвестная метрика", func(s *ScoringConfig) { s.Weights = map[string]float64{"lines": 1} }},
+		{"отрицательный вес", func(s *ScoringConfig) { s.Weights = map[string]float64{metricText: -1} }},
+		{"порог больше 100", func(s *ScoringConfig) { s.Threshold = 101 }},
+		{"порог для разных языков меньше 0", func(s *ScoringConfig) { s.CrossThreshold = -1 }},
+		{"границы уровней перепутаны", func(s *ScoringConfig) { s.MediumBand, s.HighBand = 90, 80 }},
+	}
+	for _, tt := range tests {
+		s := defaultScoringConfig()
+		tt.modify(&s)
+		if s.validate() == nil {
+			t.Errorf("%s: ошибка не обнаружена", tt.name)
+		}
+	}
+}
+
+func TestParseWeights(t *testing.T) {
+	weights, err := parseWeights(" text=3, tokens = 1.5 ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]float64{metricText: 3, metricTokens: 1.5}; !reflect.DeepEqual(weights, want) {
+		t.Errorf("веса %v, ожидалось %v", weights, want)
+	}
+	if formatted := formatWeights(weights); formatted != "text=3,tokens=1.5" {
+		t.Errorf("formatWeights = %q", formatted)
+	}
+	for _, spec := range []string{"text", "text=x"} {
+		if _, err := parseWeights(spec); err == nil {
+			t.Errorf("parseWeights(%q): ошибка не обнаружена", spec)
+		}
+	}
+}
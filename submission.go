@@ -0,0 +1,311 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// submissionOf определяет, к какой работе относится файл: первая папка пути
+// относительно корня. Файл в самом корне становится отдельной работой с именем
+// файла (alice.py), а с config.MergeRootFiles - частью работы из папки с тем же
+// именем без расширения (alice/).
+func submissionOf(root, path string) (name, dir string) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return filepath.Base(filepath.Dir(path)), filepath.Dir(path)
+	}
+
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	if len(parts) == 1 {
+		if config.MergeRootFiles {
+			name = strings.TrimSuffix(parts[0], filepath.Ext(parts[0]))
+			return name, filepath.Join(root, name)
+		}
+		return parts[0], path
+	}
+	return parts[0], filepath.Join(root, parts[0])
+}
+
+// ProjectSummary краткие сведения о работе для отчетов
+type ProjectSummary struct {
+	Name          string
+	Dir           string
+	Language      string
+	Files         []string
+	BaseRemoved   float64
+	CommonRemoved float64
+}
+
+// summary возвращает краткие сведения о работе
+func (p Project) summary() ProjectSummary {
+	summary := ProjectSummary{
+		Name:          p.Name,
+		Dir:           p.Dir,
+		Language:      p.Language,
+		BaseRemoved:   p.BaseRemoved,
+		CommonRemoved: p.CommonRemoved,
+	}
+	for _, file := range p.Files {
+		summary.Files = append(summary.Files, p.relativeFileName(file))
+	}
+	return summary
+}
+
+// summarizeProjects возвращает краткие сведения о всех работах
+func summarizeProjects(projects []Project) []ProjectSummary {
+	summaries := make([]ProjectSummary, len(projects))
+	for i, p := range projects {
+		summaries[i] = p.summary()
+	}
+	return summaries
+}
+
+// loadSubmission загружает все файлы папки как одну работу
+func loadSubmission(name, dir string) (Project, error) {
+	var files []SourceFile
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if rel, err := filepath.Rel(dir, path); err == nil && config.ignored(rel) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		lang, ok := config.fileLanguage(path)
+		if !ok {
+			return nil
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		file, _ := analyzeFileCached(path, string(content), lang)
+		files = append(files, file)
+		return nil
+	})
+	if err != nil {
+		return Project{}, fmt.Errorf("загрузка %s: %w", dir, err)
+	}
+	if len(files) == 0 {
+		return Project{}, fmt.Errorf("в %s нет файлов поддерживаемых языков", dir)
+	}
+
+	return buildProject(name, dir, files), nil
+}
+
+// buildProject собирает работу из проанализированных файлов
+func buildProject(name, dir string, files []SourceFile) Project {
+	// Стабильный порядок файлов не зависит от порядка обхода диска
+	sort.Slice(files, func(i, j int) bool { return files[i].FilePath < files[j].FilePath })
+
+	project := Project{
+		Name:  name,
+		Dir:   dir,
+		Files: files,
+	}
+	project.SourceFile = mergeSourceFiles(project.Files, dir)
+	project.FilePath = dir
+
+	return project
+}
+
+// mergeSourceFiles объединяет анализ файлов в сводный анализ работы.
+// Потоки токенов склеиваются, позиции отпечатков сдвигаются на начало файла
+// в общем потоке (TokenOffset каждого файла). Функция, объявленная в нескольких
+// файлах, получает в ключах путь файла относительно dir ("util.py:helper"),
+// чтобы одноименные функции не затирали друг друга.
+func mergeSourceFiles(files []SourceFile, dir string) SourceFile {
+	merged := SourceFile{
+		Functions: FunctionAnalysis{
+			ParamCount:    make(map[string]int),
+			ReturnTypes:   make(map[string]string),
+			FunctionSizes: make(map[string]int),
+			ParamTypes:    make(map[string]string),
+		},
+		Imports: ImportAnalysis{
+			UsagePatterns: make(map[string]string),
+		},
+	}
+
+	var comments, patterns, operators, spacing, lineBreaks []string
+	tokensByLanguage := make(map[string]int)
+	indentStyles := make(map[string]int)
+
+	declaredIn := make(map[string]int) // имя функции -> число файлов, где она объявлена
+	for _, file := range files {
+		seen := make(map[string]bool)
+		for _, name := range file.Functions.DeclareOrder {
+			if !seen[name] {
+				seen[name] = true
+				declaredIn[name]++
+			}
+		}
+	}
+
+	for i := range files {
+		file := &files[i]
+		file.TokenOffset = len(merged.Tokens.TokenPatterns)
+
+		comments = appendNonEmpty(comments, file.Comments)
+
+		ids := file.Identifiers
+		merged.Identifiers.Variables = append(merged.Identifiers.Variables, ids.Variables...)
+		merged.Identifiers.Functions = append(merged.Identifiers.Functions, ids.Functions...)
+		merged.Identifiers.Classes = append(merged.Identifiers.Classes, ids.Classes...)
+		merged.Identifiers.Interfaces = append(merged.Identifiers.Interfaces, ids.Interfaces...)
+		merged.Identifiers.Constants = append(merged.Identifiers.Constants, ids.Constants...)
+
+		cf := file.ControlFlow
+		merged.ControlFlow.IfCount += cf.IfCount
+		merged.ControlFlow.ForCount += cf.ForCount
+		merged.ControlFlow.WhileCount += cf.WhileCount
+		merged.ControlFlow.SwitchCount += cf.SwitchCount
+		merged.ControlFlow.MaxNesting = max(merged.ControlFlow.MaxNesting, cf.MaxNesting)
+		patterns = appendNonEmpty(patterns, cf.ControlPattern)
+
+		fa := file.Functions
+		key := func(name string) string {
+			if declaredIn[name] > 1 {
+				return relativePath(dir, file.FilePath) + ":" + name
+			}
+			return name
+		}
+		for _, name := range fa.DeclareOrder {
+			merged.Functions.DeclareOrder = append(merged.Functions.DeclareOrder, key(name))
+		}
+		for name, count := range fa.ParamCount {
+			merged.Functions.ParamCount[key(name)] = count
+		}
+		for name, ret := range fa.ReturnTypes {
+			merged.Functions.ReturnTypes[key(name)] = ret
+		}
+		for name, size := range fa.FunctionSizes {
+			merged.Functions.FunctionSizes[key(name)] = size
+		}
+		for name, params := range fa.ParamTypes {
+			merged.Functions.ParamTypes[key(name)] = params
+		}
+
+		merged.Imports.ImportList = append(merged.Imports.ImportList, file.Imports.ImportList...)
+		for pkg, usage := range file.Imports.UsagePatterns {
+			merged.Imports.UsagePatterns[pkg] = usage
+		}
+
+		indentStyles[file.Formatting.IndentStyle]++
+		spacing = appendNonEmpty(spacing, file.Formatting.SpacingPattern)
+		lineBreaks = appendNonEmpty(lineBreaks, file.Formatting.LineBreaks)
+
+		merged.Tokens.TokenPatterns = append(merged.Tokens.TokenPatterns, file.Tokens.TokenPatterns...)
+		merged.Tokens.TokenPositions = append(merged.Tokens.TokenPositions, file.Tokens.TokenPositions...)
+		merged.Tokens.IR = append(merged.Tokens.IR, file.Tokens.IR...)
+		operators = appendNonEmpty(operators, file.Tokens.OperatorSequence)
+		tokensByLanguage[file.Language] += len(file.Tokens.TokenPatterns) + 1
+
+		for _, fp := range file.Fingerprints {
+			merged.Fingerprints = append(merged.Fingerprints, Fingerprint{Hash: fp.Hash, Pos: fp.Pos + file.TokenOffset})
+		}
+		merged.ASTShape = append(merged.ASTShape, file.ASTShape...)
+		merged.Bodies = append(merged.Bodies, file.Bodies...)
+	}
+
+	merged.Comments = strings.Join(comments, " ")
+	merged.ControlFlow.ControlPattern = strings.Join(patterns, "|")
+	merged.Imports.ImportOrder = strings.Join(merged.Imports.ImportList, ",")
+	merged.Formatting.SpacingPattern = strings.Join(spacing, ";")
+	merged.Formatting.LineBreaks = strings.Join(lineBreaks, ",")
+	merged.Tokens.OperatorSequence = strings.Join(operators, " ")
+
+	if len(indentStyles) == 1 {
+		for style := range indentStyles {
+			merged.Formatting.IndentStyle = style
+		}
+	} else {
+		merged.Formatting.IndentStyle = "mixed"
+	}
+
+	// Язык работы - язык, на котором написана большая часть кода
+	for lang, count := range tokensByLanguage {
+		if count > tokensByLanguage[merged.Language] || (count == tokensByLanguage[merged.Language] && lang < merged.Language) {
+			merged.Language = lang
+		}
+	}
+
+	merged.Available = detectAvailableMetrics(merged)
+	return merged
+}
+
+// appendNonEmpty добавляет строку, только если она не пустая
+func appendNonEmpty(list []string, value string) []string {
+	if value == "" {
+		return list
+	}
+	return append(list, value)
+}
+
+// fileAt находит файл работы, которому принадлежит позиция в сводном потоке токенов,
+// и возвращает его индекс и позицию внутри файла
+func (p Project) fileAt(pos int) (int, int) {
+	idx := sort.Search(len(p.Files), func(i int) bool { return p.Files[i].TokenOffset > pos }) - 1
+	if idx < 0 {
+		return 0, pos
+	}
+	return idx, pos - p.Files[idx].TokenOffset
+}
+
+// relativeFileName возвращает путь файла относительно папки работы
+func (p Project) relativeFileName(file SourceFile) string {
+	return relativePath(p.Dir, file.FilePath)
+}
+
+// relativePath возвращает путь относительно dir, а для самой работы-файла - имя файла
+func relativePath(dir, path string) string {
+	if rel, err := filepath.Rel(dir, path); err == nil && rel != "." {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.Base(path)
+}
+
+// filePaths возвращает пути файлов работы на диске
+func (p Project) filePaths() []string {
+	paths := make([]string, len(p.Files))
+	for i, file := range p.Files {
+		paths[i] = file.FilePath
+	}
+	return paths
+}
+
+// compareFiles строит матрицу схожести файлов двух работ по отпечаткам winnowing
+func compareFiles(p1, p2 Project) ([]string, []string, [][]float64) {
+	names1 := make([]string, len(p1.Files))
+	for i, file := range p1.Files {
+		names1[i] = p1.relativeFileName(file)
+	}
+	names2 := make([]string, len(p2.Files))
+	for j, file := range p2.Files {
+		names2[j] = p2.relativeFileName(file)
+	}
+
+	matrix := make([][]float64, len(p1.Files))
+	for i, f1 := range p1.Files {
+		matrix[i] = make([]float64, len(p2.Files))
+		for j, f2 := range p2.Files {
+			if f1.Language != f2.Language {
+				matrix[i][j] = -1
+				continue
+			}
+			matrix[i][j], _ = compareFingerprints(f1.Fingerprints, f2.Fingerprints)
+		}
+	}
+
+	return names1, names2, matrix
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSubmissionOf(t *testing.T) {
+	root := filepath.FromSlash("/work")
+	tests := []struct {
+		path      string
+		mergeRoot bool
+		wantName  string
+		wantDir   string
+	}{
+		{"/work/alice/main.py", false, "alice", "/work/alice"},
+		{"/work/alice/lib/util.py", false, "alice", "/work/alice"},
+		{"/work/alice.py", false, "alice.py", "/work/alice.py"},
+		{"/work/alice.py", true, "alice", "/work/alice"},
+	}
+
+	saved := config
+	defer func() { config = saved }()
+	for _, tt := range tests {
+		config.MergeRootFiles = tt.mergeRoot
+		name, dir := submissionOf(root, filepath.FromSlash(tt.path))
+		if name != tt.wantName || filepath.ToSlash(dir) != tt.wantDir {
+			t.Errorf("submissionOf(%s, merge=%v) = %s, %s; ожидалось %s, %s",
+				tt.path, tt.mergeRoot, name, filepath.ToSlash(dir), tt.wantName, tt.wantDir)
+		}
+	}
+}
+
+func TestMergeSourceFilesKeepsSameNamedFunctions(t *testing.T) {
+	file := func(path string, params int) SourceFile {
+		return SourceFile{
+			FilePath: filepath.FromSlash(path),
+			Language: "python",
+			Functions: FunctionAnalysis{
+				DeclareOrder:  []string{"helper"},
+				ParamCount:    map[string]int{"helper": params},
+				ReturnTypes:   map[string]string{},
+				FunctionSizes: map[string]int{"helper": 3},
+				ParamTypes:    map[string]string{},
+			},
+		}
+	}
+	unique := file("/work/alice/main.py", 0)
+	unique.Functions.DeclareOrder = append(unique.Functions.DeclareOrder, "main")
+	unique.Functions.ParamCount["main"] = 0
+
+	merged := mergeSourceFiles([]SourceFile{unique, file("/work/alice/util.py", 2)}, filepath.FromSlash("/work/alice"))
+
+	want := map[string]int{"main.py:helper": 0, "util.py:helper": 2, "main": 0}
+	if !reflect.DeepEqual(merged.Functions.ParamCount, want) {
+		t.Errorf("ParamCount = %v, ожидалось %v", merged.Functions.ParamCount, want)
+	}
+	order := append([]string(nil), merged.Functions.DeclareOrder...)
+	sort.Strings(order)
+	if wantOrder := []string{"main", "main.py:helper", "util.py:helper"}; !reflect.DeepEqual(order, wantOrder) {
+		t.Errorf("DeclareOrder = %v, ожидалось %v", order, wantOrder)
+	}
+}
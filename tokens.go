@@ -0,0 +1,458 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// TokenKind вид лексемы
+type TokenKind int
+
+const (
+	TokenKeyword TokenKind = iota
+	TokenIdentifier
+	TokenNumber
+	TokenString
+	TokenOperator
+	TokenPunctuation
+)
+
+// Token лексема исходного кода
+type Token struct {
+	Kind TokenKind
+	Text string    // исходный текст лексемы
+	Pos  SourcePos // начало лексемы в исходном файле
+}
+
+// SourcePos позиция в исходном файле; строки и столбцы (в символах) считаются с 1
+type SourcePos struct {
+	Line   int
+	Column int
+}
+
+// SourceLocation позиция в конкретном файле работы
+type SourceLocation struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// String печатает позицию в привычном виде файл:строка:столбец;
+// неизвестная позиция печатается пустой строкой
+func (l SourceLocation) String() string {
+	if l.Line == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d:%d", l.File, l.Line, l.Column)
+}
+
+// Normalized возвращает представление лексемы для сравнения:
+// имена и литералы заменяются заглушками, чтобы переименование не влияло на результат
+func (t Token) Normalized() string {
+	switch t.Kind {
+	case TokenIdentifier:
+		return "ID"
+	case TokenNumber:
+		return "NUM"
+	case TokenString:
+		return "STR"
+	}
+	return t.Text
+}
+
+// lexerSpec описывает лексику конкретного языка
+type lexerSpec struct {
+	keywords      map[string]bool
+	lineComments  []string
+	blockComments [][2]string // пары начало/конец
+	quotes        string      // символы, открывающие строковые литералы
+	rawQuotes     []string    // строки без экранирования (`...`, """...""")
+	identPrefixes string      // символы, с которых может начинаться имя ($ в PHP, @ в Ruby)
+	lifetimes     bool        // 'a в Rust - это не символьный литерал
+	nestedBlocks  bool        // блочные комментарии вкладываются друг в друга (Rust)
+}
+
+// Размер n-граммы токенов для метрики TokenSimilarity
+const tokenNGramSize = 5
+
+// Многосимвольные операторы, отсортированные по убыванию длины
+var multiCharOperators = []string{
+	">>>=", "<<=", ">>=", "===", "!==", "...", "**=", "//=", "&^=", "<=>",
+	"&&", "||", "==", "!=", "<=", ">=", "++", "--", "+=", "-=", "*=", "/=",
+	"%=", "&=", "|=", "^=", ":=", "->", "=>", "::", "<<", ">>", "**", "//",
+	"&^", "<-", "?.", "??",
+}
+
+// Символы-разделители, которые не считаем операторами
+const punctuation = "(){}[];,."
+
+func keywordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(words) {
+		set[w] = true
+	}
+	return set
+}
+
+var cStyleComments = [][2]string{{"/*", "*/"}}
+
+// Лексика для всех языков из supportedExtensions
+var lexerSpecs = map[string]lexerSpec{
+	"golang": {
+		keywords: keywordSet(`break case chan const continue default defer else fallthrough for func go goto
+			if import interface map package range return select struct switch type var nil true false`),
+		lineComments:  []string{"//"},
+		blockComments: cStyleComments,
+		quotes:        `"'`,
+		rawQuotes:     []string{"`"},
+	},
+	"python": {
+		keywords: keywordSet(`and as assert async await break class continue def del elif else except finally
+			for from global if import in is lambda nonlocal not or pass raise return try while with yield
+			None True False`),
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+		rawQuotes:    []string{`"""`, `'''`},
+	},
+	"java": {
+		keywords: keywordSet(`abstract assert boolean break byte case catch char class const continue default
+			do double else enum extends final finally float for goto if implements import instanceof int
+			interface long native new package private protected public return short static strictfp super
+			switch synchronized this throw throws transient try void volatile while var null true false`),
+		lineComments:  []string{"//"},
+		blockComments: cStyleComments,
+		quotes:        `"'`,
+		rawQuotes:     []string{`"""`},
+	},
+	"javascript": {
+		keywords: keywordSet(`async await break case catch class const continue debugger default delete do
+			else export extends finally for function if import in instanceof let new of return static super
+			switch this throw try typeof var void while with yield null undefined true false`),
+		lineComments:  []string{"//"},
+		blockComments: cStyleComments,
+		quotes:        `"'`,
+		rawQuotes:     []string{"`"},
+		identPrefixes: "$",
+	},
+	"c++": {
+		keywords: keywordSet(`auto bool break case catch char class const constexpr continue default delete do
+			double else enum explicit extern false float for friend goto if inline int long namespace new
+			nullptr operator private protected public return short signed sizeof static struct switch
+			template this throw true try typedef typename union unsigned using virtual void volatile while
+			include define`),
+		lineComments:  []string{"//"},
+		blockComments: cStyleComments,
+		quotes:        `"'`,
+	},
+	"c": {
+		keywords: keywordSet(`auto break case char const continue default do double else enum extern float
+			for goto if inline int long register restrict return short signed sizeof static struct switch
+			typedef union unsigned void volatile while include define NULL`),
+		lineComments:  []string{"//"},
+		blockComments: cStyleComments,
+		quotes:        `"'`,
+	},
+	"c#": {
+		keywords: keywordSet(`abstract as base bool break byte case catch char checked class const continue
+			decimal default delegate do double else enum event explicit extern false finally fixed float for
+			foreach goto if implicit in int interface internal is lock long namespace new null object operator
+			out override params private protected public readonly ref return sbyte sealed short sizeof static
+			string struct switch this throw true try typeof uint ulong unchecked unsafe ushort using var
+			virtual void volatile while async await`),
+		lineComments:  []string{"//"},
+		blockComments: cStyleComments,
+		quotes:        `"'`,
+	},
+	"php": {
+		keywords: keywordSet(`abstract and array as break callable case catch class clone const continue
+			declare default do echo else elseif empty enddeclare endfor endforeach endif endswitch endwhile
+			extends final finally fn for foreach function global goto if implements include include_once
+			instanceof insteadof interface isset list match namespace new or print private protected public
+			readonly require require_once return static switch throw trait try unset use var while xor yield
+			null true false`),
+		lineComments:  []string{"//", "#"},
+		blockComments: cStyleComments,
+		quotes:        `"'`,
+		identPrefixes: "$",
+	},
+	"ruby": {
+		keywords: keywordSet(`alias and begin break case class def defined? do else elsif end ensure false for
+			if in module next nil not or redo rescue retry return self super then true undef unless until
+			when while yield require require_relative puts`),
+		lineComments:  []string{"#"},
+		blockComments: [][2]string{{"=begin", "=end"}},
+		quotes:        `"'`,
+		identPrefixes: "@$:",
+	},
+	"rust": {
+		keywords: keywordSet(`as async await break const continue crate dyn else enum extern false fn for if
+			impl in let loop match mod move mut pub ref return self Self static struct super trait true type
+			unsafe use where while`),
+		lineComments:  []string{"//"},
+		blockComments: cStyleComments,
+		quotes:        `"'`,
+		lifetimes:     true,
+		nestedBlocks:  true,
+	},
+}
+
+// tokenize разбивает исходный код на лексемы, пропуская комментарии и пробелы
+func tokenize(code, language string) []Token {
+	spec, ok := lexerSpecs[language]
+	if !ok {
+		return nil
+	}
+
+	var tokens []Token
+	src := []rune(code)
+	i := 0
+
+	hasPrefixAt := func(pos int, prefix string) bool {
+		p := []rune(prefix)
+		if pos+len(p) > len(src) {
+			return false
+		}
+		for j, r := range p {
+			if src[pos+j] != r {
+				return false
+			}
+		}
+		return true
+	}
+
+	// posAt считает строку и столбец позиции; позиции запрашиваются по возрастанию,
+	// поэтому счетчик только продвигается вперед
+	line, lineStart, counted := 1, 0, 0
+	posAt := func(pos int) SourcePos {
+		for ; counted < pos; counted++ {
+			if src[counted] == '\n' {
+				line++
+				lineStart = counted + 1
+			}
+		}
+		return SourcePos{Line: line, Column: pos - lineStart + 1}
+	}
+
+	// skipUntil возвращает позицию сразу после терминатора (или конец текста)
+	skipUntil := func(pos int, terminator string) int {
+		for pos < len(src) {
+			if hasPrefixAt(pos, terminator) {
+				return pos + len([]rune(terminator))
+			}
+			pos++
+		}
+		return pos
+	}
+
+	// skipNested пропускает вложенные блочные комментарии: каждое открытие
+	// ждет своего закрытия
+	skipNested := func(pos int, open, close string) int {
+		depth := 0
+		for pos < len(src) {
+			switch {
+			case hasPrefixAt(pos, open):
+				depth++
+				pos += len([]rune(open))
+			case hasPrefixAt(pos, close):
+				depth--
+				pos += len([]rune(close))
+				if depth == 0 {
+					return pos
+				}
+			default:
+				pos++
+			}
+		}
+		return pos
+	}
+
+scan:
+	for i < len(src) {
+		r := src[i]
+
+		if unicode.IsSpace(r) {
+			i++
+			continue
+		}
+
+		// Комментарии
+		for _, lc := range spec.lineComments {
+			if hasPrefixAt(i, lc) {
+				i = skipUntil(i, "\n")
+				continue scan
+			}
+		}
+		for _, bc := range spec.blockComments {
+			if hasPrefixAt(i, bc[0]) {
+				if spec.nestedBlocks {
+					i = skipNested(i, bc[0], bc[1])
+				} else {
+					i = skipUntil(i+len([]rune(bc[0])), bc[1])
+				}
+				continue scan
+			}
+		}
+
+		// Строки без экранирования
+		for _, rq := range spec.rawQuotes {
+			if hasPrefixAt(i, rq) {
+				start := i
+				i = skipUntil(i+len([]rune(rq)), rq)
+				tokens = append(tokens, Token{Kind: TokenString, Text: string(src[start:i]), Pos: posAt(start)})
+				continue scan
+			}
+		}
+
+		// Обычные строки и символьные литералы
+		if strings.ContainsRune(spec.quotes, r) {
+			if r == '\'' && spec.lifetimes && isLifetime(src, i) {
+				start := i
+				i++
+				for i < len(src) && isIdentRune(src[i]) {
+					i++
+				}
+				tokens = append(tokens, Token{Kind: TokenIdentifier, Text: string(src[start:i]), Pos: posAt(start)})
+				continue
+			}
+			start := i
+			i++
+			for i < len(src) && src[i] != r && src[i] != '\n' {
+				if src[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i < len(src) {
+				i++
+			}
+			if i > len(src) {
+				i = len(src)
+			}
+			tokens = append(tokens, Token{Kind: TokenString, Text: string(src[start:i]), Pos: posAt(start)})
+			continue
+		}
+
+		// Числа
+		if unicode.IsDigit(r) {
+			start := i
+			for i < len(src) && (isIdentRune(src[i]) || src[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, Token{Kind: TokenNumber, Text: string(src[start:i]), Pos: posAt(start)})
+			continue
+		}
+
+		// Ключевые слова и идентификаторы
+		if isIdentRune(r) || (strings.ContainsRune(spec.identPrefixes, r) && i+1 < len(src) && isIdentRune(src[i+1])) {
+			start := i
+			i++
+			for i < len(src) && isIdentRune(src[i]) {
+				i++
+			}
+			// Ruby допускает ? и ! в конце имени метода
+			if language == "ruby" && i < len(src) && (src[i] == '?' || src[i] == '!') {
+				i++
+			}
+			word := string(src[start:i])
+			if spec.keywords[word] {
+				tokens = append(tokens, Token{Kind: TokenKeyword, Text: word, Pos: posAt(start)})
+			} else {
+				tokens = append(tokens, Token{Kind: TokenIdentifier, Text: word, Pos: posAt(start)})
+			}
+			continue
+		}
+
+		// Операторы и разделители
+		if strings.ContainsRune(punctuation, r) && !hasPrefixAt(i, "...") && !hasPrefixAt(i, "?.") {
+			tokens = append(tokens, Token{Kind: TokenPunctuation, Text: string(r), Pos: posAt(i)})
+			i++
+			continue
+		}
+		matched := false
+		for _, op := range multiCharOperators {
+			if hasPrefixAt(i, op) {
+				tokens = append(tokens, Token{Kind: TokenOperator, Text: op, Pos: posAt(i)})
+				i += len([]rune(op))
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			tokens = append(tokens, Token{Kind: TokenOperator, Text: string(r), Pos: posAt(i)})
+			i++
+		}
+	}
+
+	return tokens
+}
+
+// isIdentRune проверяет, может ли символ входить в имя
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// isLifetime отличает время жизни Rust ('a) от символьного литерала ('a')
+func isLifetime(src []rune, pos int) bool {
+	if pos+1 >= len(src) || !isIdentRune(src[pos+1]) {
+		return false
+	}
+	return pos+2 >= len(src) || src[pos+2] != '\''
+}
+
+// analyzeTokens строит по лексемам файла нормализованный поток токенов,
+// последовательность операторов и промежуточное представление
+func analyzeTokens(tokens []Token, language string) TokenAnalysis {
+	var ta TokenAnalysis
+	var operators []string
+
+	for _, token := range tokens {
+		ta.TokenPatterns = append(ta.TokenPatterns, token.Normalized())
+		ta.TokenPositions = append(ta.TokenPositions, token.Pos)
+		if token.Kind == TokenOperator {
+			operators = append(operators, token.Text)
+		}
+	}
+
+	ta.OperatorSequence = strings.Join(operators, " ")
+	ta.IR = buildIR(tokens, language)
+	return ta
+}
+
+// tokenNGrams считает n-граммы нормализованного потока токенов
+func tokenNGrams(tokens []string, n int) map[string]int {
+	grams := make(map[string]int)
+	for i := 0; i+n <= len(tokens); i++ {
+		grams[strings.Join(tokens[i:i+n], " ")]++
+	}
+	return grams
+}
+
+// compareTokens сравнивает потоки токенов по общим n-граммам.
+// Так как имена и литералы нормализованы, переименование переменных не снижает схожесть.
+func compareTokens(t1, t2 TokenAnalysis) float64 {
+	return ngramSimilarity(t1.TokenPatterns, t2.TokenPatterns, tokenNGramSize)
+}
+
+// ngramSimilarity считает коэффициент Дайса по мультимножествам n-грамм двух последовательностей
+func ngramSimilarity(seq1, seq2 []string, n int) float64 {
+	grams1 := tokenNGrams(seq1, n)
+	grams2 := tokenNGrams(seq2, n)
+
+	total1, total2, common := 0, 0, 0
+	for gram, count1 := range grams1 {
+		total1 += count1
+		if count2, ok := grams2[gram]; ok {
+			common += min(count1, count2)
+		}
+	}
+	for _, count2 := range grams2 {
+		total2 += count2
+	}
+
+	if total1+total2 == 0 {
+		return 0
+	}
+
+	return float64(2*common) / float64(total1+total2) * 100
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// lexemes печатает лексемы как "вид:текст" для сравнения в тестах
+func lexemes(tokens []Token) []string {
+	kinds := map[TokenKind]string{
+		TokenKeyword: "K", TokenIdentifier: "I", TokenNumber: "N",
+		TokenString: "S", TokenOperator: "O", TokenPunctuation: "P",
+	}
+	var result []string
+	for _, token := range tokens {
+		result = append(result, kinds[token.Kind]+":"+token.Text)
+	}
+	return result
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		src      string
+		want     []string
+	}{
+		{
+			"сырая строка Go на несколько строк", "golang",
+			"s := `a \"b\"\n// не комментарий`",
+			[]string{"I:s", "O::=", "S:`a \"b\"\n// не комментарий`"},
+		},
+		{
+			"экранированная кавычка", "golang",
+			`x = "a\"b" + 'c'`,
+			[]string{"I:x", "O:=", `S:"a\"b"`, "O:+", "S:'c'"},
+		},
+		{
+			"строка Python в тройных кавычках", "python",
+			`doc = """он сказал "да" и 'нет'"""` + "\nx",
+			[]string{"I:doc", "O:=", `S:"""он сказал "да" и 'нет'"""`, "I:x"},
+		},
+		{
+			"шаблонная строка JavaScript", "javascript",
+			"let s = `${a}\n/* b */`;",
+			[]string{"K:let", "I:s", "O:=", "S:`${a}\n/* b */`", "P:;"},
+		},
+		{
+			"текстовый блок Java", "java",
+			"String s = \"\"\"\n  a \"q\"\n  \"\"\";",
+			[]string{"I:String", "I:s", "O:=", "S:\"\"\"\n  a \"q\"\n  \"\"\"", "P:;"},
+		},
+		{
+			"блочный комментарий C не вкладывается", "c",
+			"a /* x /* y */ b */ c",
+			[]string{"I:a", "I:b", "O:*", "O:/", "I:c"},
+		},
+		{
+			"вложенные блочные комментарии Rust", "rust",
+			"a /* x /* y */ b */ c /*/ d */ e",
+			[]string{"I:a", "I:c", "I:e"},
+		},
+		{
+			"незакрытый вложенный комментарий", "rust",
+			"a /* x /* y */ b",
+			[]string{"I:a"},
+		},
+		{
+			"строчные и блочные комментарии", "php",
+			"$a = 1; // один\n# два\n/* три\n */ $b",
+			[]string{"I:$a", "O:=", "N:1", "P:;", "I:$b"},
+		},
+		{
+			"комментарий =begin/=end в Ruby", "ruby",
+			"a\n=begin\nputs 1\n=end\nb",
+			[]string{"I:a", "I:b"},
+		},
+		{
+			"маркер комментария внутри строки", "java",
+			`s = "// нет" + "/* нет */";`,
+			[]string{"I:s", "O:=", `S:"// нет"`, "O:+", `S:"/* нет */"`, "P:;"},
+		},
+		{
+			"время жизни Rust и символьные литералы", "rust",
+			`fn f<'a>(x: &'a str) -> char { if x.is_empty() { '\n' } else { 'y' } }`,
+			[]string{
+				"K:fn", "I:f", "O:<", "I:'a", "O:>", "P:(", "I:x", "O::", "O:&", "I:'a", "I:str", "P:)",
+				"O:->", "I:char", "P:{", "K:if", "I:x", "P:.", "I:is_empty", "P:(", "P:)", "P:{", `S:'\n'`, "P:}",
+				"K:else", "P:{", "S:'y'", "P:}", "P:}",
+			},
+		},
+		{
+			"методы Ruby с ? и !", "ruby",
+			"list.empty? ? a.save! : defined?(b)",
+			[]string{"I:list", "P:.", "I:empty?", "O:?", "I:a", "P:.", "I:save!", "O::", "K:defined?", "P:(", "I:b", "P:)"},
+		},
+		{
+			"многосимвольные операторы", "javascript",
+			"a >>>= b?.c ?? [...d]",
+			[]string{"I:a", "O:>>>=", "I:b", "O:?.", "I:c", "O:??", "P:[", "O:...", "I:d", "P:]"},
+		},
+		{
+			"числа и ключевые слова", "python",
+			"for i in range(10): x = 3.14",
+			[]string{"K:for", "I:i", "K:in", "I:range", "P:(", "N:10", "P:)", "O::", "I:x", "O:=", "N:3.14"},
+		},
+		{"неизвестный язык", "cobol", "MOVE A TO B", nil},
+	}
+	for _, tt := range tests {
+		if got := lexemes(tokenize(tt.src, tt.language)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n получено  %q\n ожидалось %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNormalized(t *testing.T) {
+	got := make([]string, 0)
+	for _, token := range tokenize(`total = count + 42 * "x"`, "python") {
+		got = append(got, token.Normalized())
+	}
+	if want := []string{"ID", "=", "ID", "+", "NUM", "*", "STR"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("нормализованные лексемы %v, ожидалось %v", got, want)
+	}
+}
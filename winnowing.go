@@ -0,0 +1,123 @@
+package main
+
+import (
+	"hash/fnv"
+	"sort"
+)
+
+// Fingerprint отпечаток k-граммы токенов, отобранный алгоритмом winnowing
+type Fingerprint struct {
+	Hash uint64
+	Pos  int // индекс первого токена k-граммы в нормализованном потоке
+}
+
+// FingerprintMatch совпавший отпечаток и его позиции в обоих проектах
+type FingerprintMatch struct {
+	Hash uint64
+	Pos1 int
+	Pos2 int
+}
+
+// kGramHashes вычисляет хеш каждой k-граммы потока токенов
+func kGramHashes(tokens []string, k int) []uint64 {
+	if k <= 0 || len(tokens) < k {
+		return nil
+	}
+
+	hashes := make([]uint64, 0, len(tokens)-k+1)
+	for i := 0; i+k <= len(tokens); i++ {
+		h := fnv.New64a()
+		for _, token := range tokens[i : i+k] {
+			h.Write([]byte(token))
+			h.Write([]byte{0})
+		}
+		hashes = append(hashes, h.Sum64())
+	}
+	return hashes
+}
+
+// winnow выбирает отпечатки по алгоритму winnowing (Schleimer, Wilkerson, Aiken):
+// в каждом окне из w хешей берется самый правый минимум, повторно выбранный
+// минимум не записывается
+func winnow(hashes []uint64, w int) []Fingerprint {
+	if len(hashes) == 0 {
+		return nil
+	}
+	if w < 1 {
+		w = 1
+	}
+	// Последовательность короче окна рассматриваем как одно окно
+	if w > len(hashes) {
+		w = len(hashes)
+	}
+
+	var fingerprints []Fingerprint
+	lastPos := -1
+	for start := 0; start+w <= len(hashes); start++ {
+		minIdx := start
+		for i := start; i < start+w; i++ {
+			if hashes[i] <= hashes[minIdx] {
+				minIdx = i
+			}
+		}
+		if minIdx != lastPos {
+			fingerprints = append(fingerprints, Fingerprint{Hash: hashes[minIdx], Pos: minIdx})
+			lastPos = minIdx
+		}
+	}
+	return fingerprints
+}
+
+// fingerprintTokens строит отпечатки нормализованного потока токенов
+func fingerprintTokens(tokens []string, cfg FingerprintConfig) []Fingerprint {
+	return winnow(kGramHashes(tokens, cfg.K), cfg.Window)
+}
+
+// compareFingerprints сравнивает наборы отпечатков двух проектов.
+// Схожесть - коэффициент Дайса по различным хешам; совпадения сохраняют позиции
+// в обоих потоках, чтобы отчеты могли показать совпавшие фрагменты.
+func compareFingerprints(f1, f2 []Fingerprint) (float64, []FingerprintMatch) {
+	positions2 := make(map[uint64][]int)
+	for _, fp := range f2 {
+		positions2[fp.Hash] = append(positions2[fp.Hash], fp.Pos)
+	}
+
+	distinct1 := make(map[uint64]bool)
+	used := make(map[uint64]int)
+	var matches []FingerprintMatch
+	for _, fp := range f1 {
+		distinct1[fp.Hash] = true
+		positions, ok := positions2[fp.Hash]
+		if !ok {
+			continue
+		}
+		// Повторяющиеся отпечатки сопоставляем по порядку появления
+		idx := used[fp.Hash]
+		if idx >= len(positions) {
+			idx = len(positions) - 1
+		}
+		used[fp.Hash]++
+		matches = append(matches, FingerprintMatch{Hash: fp.Hash, Pos1: fp.Pos, Pos2: positions[idx]})
+	}
+
+	common := 0
+	for hash := range distinct1 {
+		if _, ok := positions2[hash]; ok {
+			common++
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Pos1 != matches[j].Pos1 {
+			return matches[i].Pos1 < matches[j].Pos1
+		}
+		return matches[i].Pos2 < matches[j].Pos2
+	})
+
+	total := len(distinct1) + len(positions2)
+	if total == 0 {
+		return 0, matches
+	}
+
+	return float64(2*common) / float64(total) * 100, matches
+}
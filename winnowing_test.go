@@ -0,0 +1,117 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKGramHashes(t *testing.T) {
+	tokens := strings.Fields("a b c a b c")
+	hashes := kGramHashes(tokens, 3)
+	if len(hashes) != 4 {
+		t.Fatalf("k-грамм %d, ожидалось 4", len(hashes))
+	}
+	if hashes[0] != hashes[3] || hashes[0] == hashes[1] {
+		t.Errorf("одинаковые k-граммы должны давать одинаковый хеш, разные - разный: %v", hashes)
+	}
+	if kGramHashes(tokens, 7) != nil || kGramHashes(tokens, 0) != nil {
+		t.Error("k-граммы длиннее потока или нулевой длины должны давать nil")
+	}
+	// Разделитель между токенами: "ab c" и "a bc" - разные k-граммы
+	if kGramHashes([]string{"ab", "c"}, 2)[0] == kGramHashes([]string{"a", "bc"}, 2)[0] {
+		t.Error("склейка токенов дала одинаковый хеш")
+	}
+}
+
+func TestWinnow(t *testing.T) {
+	tests := []struct {
+		name   string
+		hashes []uint64
+		w      int
+		want   []Fingerprint
+	}{
+		{"пусто", nil, 4, nil},
+		{
+			"самый правый минимум, без повторов",
+			[]uint64{5, 3, 4, 3, 6, 1, 2}, 3,
+			[]Fingerprint{{Hash: 3, Pos: 1}, {Hash: 3, Pos: 3}, {Hash: 1, Pos: 5}},
+		},
+		{"окно длиннее потока", []uint64{4, 2, 9}, 5, []Fingerprint{{Hash: 2, Pos: 1}}},
+		{
+			"окно 0 - как окно 1",
+			[]uint64{7, 7, 1}, 0,
+			[]Fingerprint{{Hash: 7, Pos: 0}, {Hash: 7, Pos: 1}, {Hash: 1, Pos: 2}},
+		},
+	}
+	for _, tt := range tests {
+		if got := winnow(tt.hashes, tt.w); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: %v, ожидалось %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// Гарантия winnowing: общий отрезок не короче w+k-1 токенов дает общий отпечаток
+func TestWinnowGuarantee(t *testing.T) {
+	cfg := FingerprintConfig{K: 3, Window: 4}
+	shared := strings.Fields("x = a [ i ] + b ; y")
+	a := append(strings.Fields("p q r s t"), shared...)
+	b := append(append(strings.Fields("u v"), shared...), strings.Fields("w w w")...)
+	_, matches := compareFingerprints(fingerprintTokens(a, cfg), fingerprintTokens(b, cfg))
+	if len(matches) == 0 {
+		t.Fatal("общий отрезок из", len(shared), "токенов не дал общих отпечатков")
+	}
+	for _, m := range matches {
+		if m.Pos1-5 != m.Pos2-2 {
+			t.Errorf("совпадение %+v вне общего отрезка", m)
+		}
+	}
+}
+
+func TestCompareFingerprints(t *testing.T) {
+	fps := func(hashes ...uint64) []Fingerprint {
+		var result []Fingerprint
+		for i, h := range hashes {
+			result = append(result, Fingerprint{Hash: h, Pos: i})
+		}
+		return result
+	}
+	tests := []struct {
+		name    string
+		f1, f2  []Fingerprint
+		want    float64
+		matches []FingerprintMatch
+	}{
+		{"одинаковые", fps(1, 2), fps(1, 2), 100, []FingerprintMatch{{1, 0, 0}, {2, 1, 1}}},
+		{"без общих", fps(1, 2), fps(3, 4), 0, nil},
+		{"половина", fps(1, 2, 3, 4), fps(3, 4, 5, 6), 50, []FingerprintMatch{{3, 2, 0}, {4, 3, 1}}},
+		{"повторы сопоставляются по порядку", fps(1, 9, 1), fps(1, 1), 2.0 / 3 * 100, []FingerprintMatch{{1, 0, 0}, {1, 2, 1}}},
+		{"пустые", nil, nil, 0, nil},
+	}
+	for _, tt := range tests {
+		got, matches := compareFingerprints(tt.f1, tt.f2)
+		if math.Abs(got-tt.want) > 1e-9 || !reflect.DeepEqual(matches, tt.matches) {
+			t.Errorf("%s: %.2f %v, ожидалось %.2f %v", tt.name, got, matches, tt.want, tt.matches)
+		}
+		if reverse, _ := compareFingerprints(tt.f2, tt.f1); reverse != got {
+			t.Errorf("%s: несимметрично: %.2f и %.2f", tt.name, got, reverse)
+		}
+	}
+}
+
+func TestFingerprintsIgnoreRenaming(t *testing.T) {
+	normalized := func(src string) []string {
+		var result []string
+		for _, token := range tokenize(src, "python") {
+			result = append(result, token.Normalized())
+		}
+		return result
+	}
+	cfg := FingerprintConfig{K: 5, Window: 4}
+	original := normalized("def total(xs):\n    s = 0\n    for x in xs:\n        s += x * 2\n    return s\n")
+	renamed := normalized("def add(items):\n    acc = 1\n    for v in items:\n        acc += v * 3\n    return acc\n")
+	if similarity, _ := compareFingerprints(fingerprintTokens(original, cfg), fingerprintTokens(renamed, cfg)); similarity != 100 {
+		t.Errorf("переименованная копия: %.2f%%, ожидалось 100%%", similarity)
+	}
+}